@@ -0,0 +1,49 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+
+    "github.com/YuHover/json2ast"
+)
+
+func runAst(args []string) int {
+    var fs = flag.NewFlagSet("ast", flag.ExitOnError)
+    var format = fs.String("format", "tree", "dump format: tree, json or sexpr")
+    fs.Usage = func() {
+        fmt.Fprintln(os.Stderr, "usage: json2ast ast [-format tree|json|sexpr] [file ...]")
+        fs.PrintDefaults()
+    }
+    _ = fs.Parse(args)
+
+    var dump func(json2ast.JsonAst) string
+    switch *format {
+    case "tree":
+        dump = json2ast.Dump
+    case "json":
+        dump = func(ast json2ast.JsonAst) string { return string(json2ast.DumpJSON(ast)) + "\n" }
+    case "sexpr":
+        dump = json2ast.DumpSexpr
+    default:
+        fmt.Fprintln(os.Stderr, "json2ast: unknown dump format", *format)
+        return 2
+    }
+
+    inputs, err := readInputs(fs.Args())
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "json2ast:", err)
+        return 1
+    }
+
+    var status = 0
+    for _, in := range inputs {
+        ast, ok := parse(in)
+        if !ok {
+            status = 1
+            continue
+        }
+        fmt.Print(dump(ast))
+    }
+    return status
+}
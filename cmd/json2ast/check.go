@@ -0,0 +1,36 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+
+    "github.com/YuHover/json2ast"
+)
+
+func runCheck(args []string) int {
+    var fs = flag.NewFlagSet("check", flag.ExitOnError)
+    var quiet = fs.Bool("q", false, "do not print diagnostics, only set the exit status")
+    fs.Usage = func() {
+        fmt.Fprintln(os.Stderr, "usage: json2ast check [-q] [file ...]")
+        fs.PrintDefaults()
+    }
+    _ = fs.Parse(args)
+
+    inputs, err := readInputs(fs.Args())
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "json2ast:", err)
+        return 1
+    }
+
+    var status = 0
+    for _, in := range inputs {
+        _, jerrs := json2ast.Parser(in.source)
+        if len(jerrs) != 0 { status = 1 }
+        if *quiet { continue }
+        for _, jerr := range jerrs {
+            printDiagnostic(os.Stderr, in, jerr.Line(), jerr.Column(), jerr.Type().String())
+        }
+    }
+    return status
+}
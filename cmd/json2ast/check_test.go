@@ -0,0 +1,32 @@
+package main
+
+import (
+    "testing"
+)
+
+func TestCheck(t *testing.T) {
+    var tests = []struct {
+        args   []string
+        stdin  string
+        status int
+        stderr string
+    }{
+        {nil, `{"a": [1, 2]}`, 0, ""},
+        {nil, "[1 2]", 1, "<stdin>:1:4: CommaOrClosingBracketExpected\n    [1 2]\n       ^\n"},
+        {nil, "{\"a\":1,\n\"b\" 2", 1, "<stdin>:2:5: ColonExpected\n    \"b\" 2\n        ^\n<stdin>:2:6: CommaOrClosingBraceExpected\n    \"b\" 2\n         ^\n"},
+        {nil, "", 1, "<stdin>:1:1: ValueExpected\n    \n    ^\n"},
+        {[]string{"-q"}, "[1 2]", 1, ""},
+        {[]string{"-q"}, "[1, 2]", 0, ""},
+    }
+
+    for _, tt := range tests {
+        status, _, stderr := runCommand(t, runCheck, tt.args, tt.stdin)
+        if status != tt.status || stderr != tt.stderr {
+            t.Errorf("check %v of %q exits with %d and prints\n%q, want %d and\n%q", tt.args, tt.stdin, status, stderr, tt.status, tt.stderr)
+        }
+    }
+
+    if status, _, _ := runCommand(t, runCheck, []string{"no such file"}, ""); status != 1 {
+        t.Errorf("check of a missing file exits with %d", status)
+    }
+}
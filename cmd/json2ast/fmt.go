@@ -0,0 +1,88 @@
+package main
+
+import (
+    "bytes"
+    "flag"
+    "fmt"
+    "os"
+
+    "github.com/YuHover/json2ast"
+)
+
+func runFmt(args []string) int {
+    var fs = flag.NewFlagSet("fmt", flag.ExitOnError)
+    var write = fs.Bool("w", false, "write the result to the file instead of the standard output,\nfiles with duplicate keys are left as they are")
+    var compact = fs.Bool("c", false, "compact output without whitespace")
+    var indent = fs.String("indent", "    ", "indentation of nested levels")
+    fs.Usage = func() {
+        fmt.Fprintln(os.Stderr, "usage: json2ast fmt [-w] [-c] [-indent str] [file ...]")
+        fs.PrintDefaults()
+    }
+    _ = fs.Parse(args)
+
+    if *write && fs.NArg() == 0 {
+        fmt.Fprintln(os.Stderr, "json2ast: cannot use -w with the standard input")
+        return 2
+    }
+    inputs, err := readInputs(fs.Args())
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "json2ast:", err)
+        return 1
+    }
+
+    var status = 0
+    for _, in := range inputs {
+        ast, ok := parse(in)
+        if !ok {
+            status = 1
+            continue
+        }
+
+        var out []byte
+        if *compact {
+            out, _ = ast.MarshalJSON()
+        } else {
+            out = json2ast.FormatIndent(ast, *indent)
+        }
+        out = append(out, '\n')
+
+        if !*write {
+            os.Stdout.Write(out)
+            continue
+        }
+        if bytes.Equal(out, []byte(in.source)) { continue }
+        // the ast keeps the last of duplicate members, writing it back would lose the others
+        if tree, _ := json2ast.ParseTree(in.source); tree != nil {
+            if dups := duplicateMembers(tree.Root(), nil); len(dups) != 0 {
+                for _, dup := range dups {
+                    printDiagnostic(os.Stderr, in, dup.Loc().Line(), dup.Loc().Column(), "duplicate key "+dup.Key()+", not written")
+                }
+                status = 1
+                continue
+            }
+        }
+        info, err := os.Stat(in.name)
+        if err == nil {
+            err = os.WriteFile(in.name, out, info.Mode().Perm())
+        }
+        if err != nil {
+            fmt.Fprintln(os.Stderr, "json2ast:", err)
+            status = 1
+        }
+    }
+    return status
+}
+
+// duplicateMembers appends to dups the members of the objects under n whose key is
+// the key of an earlier member of the same object
+func duplicateMembers(n json2ast.Node, dups []json2ast.Node) []json2ast.Node {
+    var seen = map[string]bool{}
+    for _, c := range n.Children() {
+        if n.Type() == json2ast.Object {
+            if seen[c.Key()] { dups = append(dups, c) }
+            seen[c.Key()] = true
+        }
+        dups = duplicateMembers(c, dups)
+    }
+    return dups
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestFmt(t *testing.T) {
+    var tests = []struct {
+        args   []string
+        stdin  string
+        status int
+        stdout string
+    }{
+        {nil, `{"a":[1,{"b":null}],"c":"\u00e9"}`, 0, "{\n    \"a\": [\n        1,\n        {\n            \"b\": null\n        }\n    ],\n    \"c\": \"\\u00e9\"\n}\n"},
+        {[]string{"-indent", "  "}, `{"b": {}, "a": []}`, 0, "{\n  \"b\": {},\n  \"a\": []\n}\n"},
+        {[]string{"-c"}, "[ 1 ,\n\t\"x\" , true ]", 0, "[1,\"x\",true]\n"},
+        {[]string{"-c"}, `{"a": 1, "a": 2}`, 0, "{\"a\":2}\n"},
+        {nil, "[1 2]", 1, ""},
+        {[]string{"-w"}, "[]", 2, ""},
+    }
+
+    for _, tt := range tests {
+        status, stdout, _ := runCommand(t, runFmt, tt.args, tt.stdin)
+        if status != tt.status || stdout != tt.stdout {
+            t.Errorf("fmt %v of %q exits with %d and prints\n%q, want %d and\n%q", tt.args, tt.stdin, status, stdout, tt.status, tt.stdout)
+        }
+    }
+}
+
+func TestFmtWrite(t *testing.T) {
+    var tests = []struct {
+        source string
+        status int
+        want   string // the file after fmt -w
+    }{
+        {`{"a":[1,2]}`, 0, "{\n    \"a\": [\n        1,\n        2\n    ]\n}\n"},
+        {"[]\n", 0, "[]\n"},
+        {"[1 2]", 1, "[1 2]"},
+        // the duplicate members would be lost
+        {`{"a": 1, "b": {"c": 1, "c": 2}}`, 1, `{"a": 1, "b": {"c": 1, "c": 2}}`},
+        {`[{"a": 1, "a": 1}]`, 1, `[{"a": 1, "a": 1}]`},
+    }
+
+    for _, tt := range tests {
+        var name = filepath.Join(t.TempDir(), "in.json")
+        if err := os.WriteFile(name, []byte(tt.source), 0o600); err != nil {
+            t.Fatal(err)
+        }
+        status, stdout, _ := runCommand(t, runFmt, []string{"-w", name}, "")
+        b, _ := os.ReadFile(name)
+        if status != tt.status || stdout != "" || string(b) != tt.want {
+            t.Errorf("fmt -w of %q exits with %d and writes\n%q, want %d and\n%q", tt.source, status, b, tt.status, tt.want)
+        }
+        if info, _ := os.Stat(name); info.Mode().Perm() != 0o600 {
+            t.Errorf("fmt -w of %q changes the mode to %v", tt.source, info.Mode())
+        }
+    }
+}
+
+func TestFmtDuplicates(t *testing.T) {
+    var name = filepath.Join(t.TempDir(), "dup.json")
+    if err := os.WriteFile(name, []byte("{\"a\": 1,\n \"b\": [{\"x\":1,\"x\":2}], \"a\": 3}"), 0o600); err != nil {
+        t.Fatal(err)
+    }
+    _, _, stderr := runCommand(t, runFmt, []string{"-w", name}, "")
+    var want = name + ":2:19: duplicate key \"x\", not written\n" +
+        "     \"b\": [{\"x\":1,\"x\":2}], \"a\": 3}\n" +
+        "                      ^\n" +
+        name + ":2:29: duplicate key \"a\", not written\n" +
+        "     \"b\": [{\"x\":1,\"x\":2}], \"a\": 3}\n" +
+        "                                ^\n"
+    if stderr != want {
+        t.Errorf("fmt -w prints\n%s, want\n%s", stderr, want)
+    }
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+
+    "github.com/YuHover/json2ast"
+)
+
+func runGoTypes(args []string) int {
+    var fs = flag.NewFlagSet("gotypes", flag.ExitOnError)
+    var typeName = fs.String("type", "Document", "name of the root type")
+    var pkg = fs.String("package", "", "emit a package clause with this name")
+    fs.Usage = func() {
+        fmt.Fprintln(os.Stderr, "usage: json2ast gotypes [-type name] [-package name] [file]")
+        fs.PrintDefaults()
+    }
+    _ = fs.Parse(args)
+    if fs.NArg() > 1 {
+        fs.Usage()
+        return 2
+    }
+
+    inputs, err := readInputs(fs.Args())
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "json2ast:", err)
+        return 1
+    }
+    ast, ok := parse(inputs[0])
+    if !ok {
+        return 1
+    }
+
+    src, err := json2ast.GenerateGoTypes(ast, *typeName)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "json2ast:", err)
+        return 1
+    }
+    if *pkg != "" {
+        fmt.Printf("package %s\n\n", *pkg)
+    }
+    os.Stdout.Write(src)
+    return 0
+}
@@ -0,0 +1,126 @@
+// Command json2ast works with json documents through package json2ast.
+//
+//     json2ast <command> [flags] [file ...]
+//
+// files are read from the standard input when none is given or the name is "-"
+package main
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "sort"
+    "strings"
+    "unicode/utf8"
+
+    "github.com/YuHover/json2ast"
+)
+
+type command struct {
+    run   func(args []string) int
+    usage string
+}
+
+var commands = map[string]command{
+    "check":   {runCheck, "validate documents and print diagnostics"},
+    "fmt":     {runFmt, "pretty or compact print documents"},
+    "ast":     {runAst, "dump the syntax tree of a document"},
+    "tokens":  {runTokens, "dump the token stream of a document"},
+    "query":   {runQuery, "print the node at a json pointer or path"},
+    "gotypes": {runGoTypes, "generate go type declarations from a sample document"},
+}
+
+func usage() {
+    fmt.Fprintln(os.Stderr, "usage: json2ast <command> [flags] [file ...]")
+    fmt.Fprintln(os.Stderr)
+    fmt.Fprintln(os.Stderr, "commands:")
+    var names []string
+    for name := range commands {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        fmt.Fprintf(os.Stderr, "    %-10s %s\n", name, commands[name].usage)
+    }
+}
+
+func main() {
+    if len(os.Args) < 2 {
+        usage()
+        os.Exit(2)
+    }
+    cmd, ok := commands[os.Args[1]]
+    if !ok {
+        fmt.Fprintf(os.Stderr, "json2ast: unknown command %q\n", os.Args[1])
+        usage()
+        os.Exit(2)
+    }
+    os.Exit(cmd.run(os.Args[2:]))
+}
+
+type input struct {
+    name   string
+    source string
+}
+
+// readInputs reads the named files, or the standard input when there are none
+func readInputs(names []string) ([]input, error) {
+    if len(names) == 0 {
+        names = []string{"-"}
+    }
+
+    var inputs []input
+    for _, name := range names {
+        var b []byte
+        var err error
+        if name == "-" {
+            name = "<stdin>"
+            b, err = io.ReadAll(os.Stdin)
+        } else {
+            b, err = os.ReadFile(name)
+        }
+        if err != nil {
+            return nil, err
+        }
+        inputs = append(inputs, input{name, string(b)})
+    }
+    return inputs, nil
+}
+
+// parse parses the input, reporting errors on the standard error
+func parse(in input) (json2ast.JsonAst, bool) {
+    ast, jerrs := json2ast.Parser(in.source)
+    for _, jerr := range jerrs {
+        printDiagnostic(os.Stderr, in, jerr.Line(), jerr.Column(), jerr.Type().String())
+    }
+    return ast, len(jerrs) == 0
+}
+
+// printDiagnostic prints the message with the source line and a caret under the column:
+//
+//     config.json:2:12: CommaExpected
+//         "a": 1 "b": 2
+//                ^
+func printDiagnostic(w io.Writer, in input, line, col int, msg string) {
+    var lines = strings.Split(in.source, "\n")
+    if line < 1 || line > len(lines) { // the end of input, after the last non-blank character
+        lines = strings.Split(strings.TrimRight(in.source, " \t\r\n"), "\n")
+        line = len(lines)
+        col = utf8.RuneCountInString(lines[line-1]) + 1
+    }
+    var text = strings.TrimSuffix(lines[line-1], "\r")
+
+    var caret strings.Builder
+    for i, r := range []rune(text) {
+        if i >= col-1 { break }
+        if r == '\t' {
+            caret.WriteRune('\t')
+        } else {
+            caret.WriteRune(' ')
+        }
+    }
+    caret.WriteRune('^')
+
+    fmt.Fprintf(w, "%s:%d:%d: %s\n", in.name, line, col, msg)
+    fmt.Fprintf(w, "    %s\n    %s\n", text, caret.String())
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// runCommand runs a command with stdin as the standard input, it gives the exit status
+// and what was written on the standard output and error
+func runCommand(t *testing.T, run func(args []string) int, args []string, stdin string) (int, string, string) {
+    var dir = t.TempDir()
+    var files [3]*os.File
+    for i, name := range []string{"stdin", "stdout", "stderr"} {
+        f, err := os.Create(filepath.Join(dir, name))
+        if err != nil {
+            t.Fatal(err)
+        }
+        defer f.Close()
+        files[i] = f
+    }
+    if _, err := files[0].WriteString(stdin); err != nil {
+        t.Fatal(err)
+    }
+    _, _ = files[0].Seek(0, 0)
+
+    var saved = [3]*os.File{os.Stdin, os.Stdout, os.Stderr}
+    os.Stdin, os.Stdout, os.Stderr = files[0], files[1], files[2]
+    var status = run(args)
+    os.Stdin, os.Stdout, os.Stderr = saved[0], saved[1], saved[2]
+
+    stdout, _ := os.ReadFile(files[1].Name())
+    stderr, _ := os.ReadFile(files[2].Name())
+    return status, string(stdout), string(stderr)
+}
+
+func TestPrintDiagnostic(t *testing.T) {
+    var tests = []struct {
+        source    string
+        line, col int
+        want      string
+    }{
+        {"[1 2]", 1, 4, "in.json:1:4: msg\n    [1 2]\n       ^\n"},
+        {"{\r\n\t\"a\" 1}", 2, 6, "in.json:2:6: msg\n    \t\"a\" 1}\n    \t    ^\n"},
+        {"[\"é\" 1]", 1, 6, "in.json:1:6: msg\n    [\"é\" 1]\n         ^\n"},
+        // the end of input is after the last non-blank character
+        {"[1,\n  2\n\n", -1, -1, "in.json:2:4: msg\n      2\n       ^\n"},
+        {"", -1, -1, "in.json:1:1: msg\n    \n    ^\n"},
+    }
+
+    for _, tt := range tests {
+        var buf bytes.Buffer
+        printDiagnostic(&buf, input{"in.json", tt.source}, tt.line, tt.col, "msg")
+        if buf.String() != tt.want {
+            t.Errorf("diagnostic at [%d, %d] of %q is\n%q, want\n%q", tt.line, tt.col, tt.source, buf.String(), tt.want)
+        }
+    }
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/YuHover/json2ast"
+)
+
+func runQuery(args []string) int {
+    var fs = flag.NewFlagSet("query", flag.ExitOnError)
+    var raw = fs.Bool("r", false, "print strings without quotes and escapes")
+    var compact = fs.Bool("c", false, "compact output without whitespace")
+    fs.Usage = func() {
+        fmt.Fprintln(os.Stderr, "usage: json2ast query [-r] [-c] <pointer | path> [file]")
+        fmt.Fprintln(os.Stderr, "a json pointer looks like /servers/0/name, a path like servers[0].name")
+        fs.PrintDefaults()
+    }
+    _ = fs.Parse(args)
+    if fs.NArg() < 1 || fs.NArg() > 2 {
+        fs.Usage()
+        return 2
+    }
+
+    pointer, err := pathToPointer(fs.Arg(0))
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "json2ast:", err)
+        return 2
+    }
+    inputs, err := readInputs(fs.Args()[1:])
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "json2ast:", err)
+        return 1
+    }
+    ast, ok := parse(inputs[0])
+    if !ok {
+        return 1
+    }
+
+    node, err := json2ast.Lookup(ast, pointer)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "%s: %v\n", inputs[0].name, err)
+        return 1
+    }
+
+    if *raw && node.Typ == json2ast.Literal && node.LiteralAst.Typ == json2ast.String {
+        var s string
+        _ = json2ast.Decode(node, &s)
+        fmt.Println(s)
+        return 0
+    }
+    var out []byte
+    if *compact {
+        out, _ = node.MarshalJSON()
+    } else {
+        out = json2ast.FormatIndent(node, "    ")
+    }
+    fmt.Printf("%s\n", out)
+    return 0
+}
+
+// pathToPointer converts a path like servers[0].name into the json pointer /servers/0/name,
+// json pointers and the empty path are returned as they are
+func pathToPointer(path string) (string, error) {
+    if path == "" || path[0] == '/' {
+        return path, nil
+    }
+
+    var sb strings.Builder
+    var escape = strings.NewReplacer("~", "~0", "/", "~1")
+    for _, part := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+        if part == "" {
+            return "", fmt.Errorf("invalid path %q", path)
+        }
+        for part != "" {
+            var i = strings.IndexByte(part, '[')
+            if i < 0 {
+                sb.WriteString("/" + escape.Replace(part))
+                break
+            }
+            if i > 0 {
+                sb.WriteString("/" + escape.Replace(part[:i]))
+            }
+            var j = strings.IndexByte(part, ']')
+            if j < i {
+                return "", fmt.Errorf("invalid path %q", path)
+            }
+            sb.WriteString("/" + part[i+1:j])
+            part = part[j+1:]
+        }
+    }
+    return sb.String(), nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+    "testing"
+)
+
+func TestPathToPointer(t *testing.T) {
+    var validTests = []struct {
+        path    string
+        pointer string
+    }{
+        {"", ""},
+        {"/", "/"},
+        {"/servers/0/name", "/servers/0/name"},
+        {"servers[0].name", "/servers/0/name"},
+        {".servers[0].name", "/servers/0/name"},
+        {"a", "/a"},
+        {"[1][2]", "/1/2"},
+        {"a[0][1].b", "/a/0/1/b"},
+        {"a/b.c~d", "/a~1b/c~0d"},
+    }
+    for _, vt := range validTests {
+        pointer, err := pathToPointer(vt.path)
+        if err != nil || pointer != vt.pointer {
+            t.Errorf("path %q gives %q, %v, want %q", vt.path, pointer, err, vt.pointer)
+        }
+    }
+
+    var invalidTests = []string{"a..b", "a.", ".", "a]0[", "a[0"}
+    for _, ivt := range invalidTests {
+        if pointer, err := pathToPointer(ivt); err == nil {
+            t.Errorf("path %q gives %q, want an error", ivt, pointer)
+        }
+    }
+}
+
+func TestQuery(t *testing.T) {
+    var source = `{"servers": [{"name": "a\tb", "ports": [80, 443]}], "a/b": null}`
+    var tests = []struct {
+        args   []string
+        status int
+        stdout string
+    }{
+        {[]string{"servers[0].name"}, 0, "\"a\\tb\"\n"},
+        {[]string{"-r", "/servers/0/name"}, 0, "a\tb\n"},
+        {[]string{"-c", "servers[0]"}, 0, "{\"name\":\"a\\tb\",\"ports\":[80,443]}\n"},
+        {[]string{"servers[0].ports"}, 0, "[\n    80,\n    443\n]\n"},
+        {[]string{"/a~1b"}, 0, "null\n"},
+        {[]string{"servers[1]"}, 1, ""},
+        {[]string{"a..b"}, 2, ""},
+        {nil, 2, ""},
+    }
+
+    for _, tt := range tests {
+        status, stdout, _ := runCommand(t, runQuery, tt.args, source)
+        if status != tt.status || stdout != tt.stdout {
+            t.Errorf("query %v exits with %d and prints %q, want %d and %q", tt.args, status, stdout, tt.status, tt.stdout)
+        }
+    }
+}
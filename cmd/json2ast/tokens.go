@@ -0,0 +1,37 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "os"
+
+    "github.com/YuHover/json2ast"
+)
+
+func runTokens(args []string) int {
+    var fs = flag.NewFlagSet("tokens", flag.ExitOnError)
+    fs.Usage = func() {
+        fmt.Fprintln(os.Stderr, "usage: json2ast tokens [file ...]")
+        fs.PrintDefaults()
+    }
+    _ = fs.Parse(args)
+
+    inputs, err := readInputs(fs.Args())
+    if err != nil {
+        fmt.Fprintln(os.Stderr, "json2ast:", err)
+        return 1
+    }
+
+    var status = 0
+    for _, in := range inputs {
+        tokens, jerrs := json2ast.Tokenize(in.source)
+        for _, token := range tokens {
+            fmt.Printf("%d:%d-%d:%d\t%-12s %s\n", token.Span.Start.Line, token.Span.Start.Column, token.Span.End.Line, token.Span.End.Column, token.Typ, token.Val)
+        }
+        for _, jerr := range jerrs {
+            printDiagnostic(os.Stderr, in, jerr.Line(), jerr.Column(), jerr.Type().String())
+        }
+        if len(jerrs) != 0 { status = 1 }
+    }
+    return status
+}
@@ -0,0 +1,103 @@
+package json2ast
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+// implementationDefined decides, for every i_ case of the JSONTestSuite corpus,
+// whether Parser accepts it. a new i_ file without a decision fails the test
+var implementationDefined = map[string]bool {
+    // numbers are kept as their text, so any size is accepted, converting
+    // them later may fail with NumberOutOfRange
+    "i_number_double_huge_neg_exp": true,
+    "i_number_huge_exp": true,
+    "i_number_neg_int_huge_exp": true,
+    "i_number_pos_double_huge_exp": true,
+    "i_number_real_neg_overflow": true,
+    "i_number_real_pos_overflow": true,
+    "i_number_real_underflow": true,
+    "i_number_too_big_neg_int": true,
+    "i_number_too_big_pos_int": true,
+    "i_number_very_big_negative_int": true,
+
+    // lone surrogates are reported as LoneSurrogate (Options.ReplaceInvalid accepts them)
+    "i_object_key_lone_2nd_surrogate": false,
+    "i_string_1st_surrogate_but_2nd_missing": false,
+    "i_string_1st_valid_surrogate_2nd_invalid": false,
+    "i_string_incomplete_surrogate_and_escape_valid": false,
+    "i_string_incomplete_surrogate_pair": false,
+    "i_string_incomplete_surrogates_escape_valid": false,
+    "i_string_invalid_lonely_surrogate": false,
+    "i_string_invalid_surrogate": false,
+    "i_string_inverted_surrogates_U+1D11E": false,
+    "i_string_lone_second_surrogate": false,
+
+    // the input must be utf-8, anything else is reported as InvalidUTF8 or InvalidToken
+    "i_string_UTF-8_invalid_sequence": false,
+    "i_string_UTF8_surrogate_U+D800": false,
+    "i_string_invalid_utf-8": false,
+    "i_string_iso_latin_1": false,
+    "i_string_lone_utf8_continuation_byte": false,
+    "i_string_not_in_unicode_range": false,
+    "i_string_overlong_sequence_2_bytes": false,
+    "i_string_overlong_sequence_6_bytes": false,
+    "i_string_overlong_sequence_6_bytes_null": false,
+    "i_string_truncated-utf-8": false,
+    "i_string_UTF-16LE_with_BOM": false,
+    "i_string_utf16BE_no_BOM": false,
+    "i_string_utf16LE_no_BOM": false,
+
+    // a byte order mark is not json whitespace
+    "i_structure_UTF-8_BOM_empty_object": false,
+
+    // the parser keeps its stack on the heap, nesting is only limited by Options.MaxDepth
+    "i_structure_500_nested_arrays": true,
+}
+
+// TestJSONTestSuite runs the test_parsing cases of JSONTestSuite (testdata/JSONTestSuite):
+// y_ cases must be accepted, n_ cases rejected and i_ cases decided as implementationDefined says
+func TestJSONTestSuite(t *testing.T) {
+    files, err := filepath.Glob(filepath.Join("testdata", "JSONTestSuite", "test_parsing", "*.json"))
+    if err != nil || len(files) == 0 {
+        t.Fatalf("no cases in testdata/JSONTestSuite/test_parsing: %v", err)
+    }
+
+    var passed, failed int
+    for _, file := range files {
+        var name = strings.TrimSuffix(filepath.Base(file), ".json")
+        source, err := os.ReadFile(file)
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        var accept bool
+        switch name[:2] {
+        case "y_":
+            accept = true
+        case "n_":
+            accept = false
+        case "i_":
+            var ok bool
+            if accept, ok = implementationDefined[name]; !ok {
+                t.Errorf("%s: no decided behaviour in implementationDefined", name)
+                failed++
+                continue
+            }
+        default:
+            continue
+        }
+
+        _, jerrs := Parser(string(source))
+        if accepted := len(jerrs) == 0; accepted != accept {
+            t.Errorf("%s: accepted %v, want %v, errors %v", name, accepted, accept, jerrs)
+            failed++
+            continue
+        }
+        passed++
+    }
+
+    t.Logf("JSONTestSuite: %d passed, %d failed", passed, failed)
+}
@@ -0,0 +1,440 @@
+package json2ast
+
+import (
+    "encoding"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "strconv"
+    "strings"
+)
+
+// DecodeError describes a json node that can not be stored into the go value
+type DecodeError struct {
+    Path   string // json pointer of the node
+    Line   int
+    Column int
+    Msg    string
+}
+
+func (derr *DecodeError) Error() string {
+    return fmt.Sprintf("[%d, %d], %s: %s", derr.Line, derr.Column, derr.Path, derr.Msg)
+}
+
+var (
+    textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+    numberType          = reflect.TypeOf(json.Number(""))
+    astType             = reflect.TypeOf(JsonAst{})
+)
+
+// Decode stores the value of ast into v, which must be a non-nil pointer.
+// it follows the rules of encoding/json.Unmarshal: `json` struct tags (with the ,string option),
+// embedded structs, maps, slices, pointers, json.Unmarshaler and encoding.TextUnmarshaler are
+// honoured, strings holding a number decode into json.Number and base64 strings into []byte.
+// JsonAst fields receive the node itself
+func Decode(ast JsonAst, v interface{}) error {
+    var rv = reflect.ValueOf(v)
+    if rv.Kind() != reflect.Ptr || rv.IsNil() {
+        return fmt.Errorf("decode into non-pointer or nil value %s", reflect.TypeOf(v))
+    }
+    return decodeValue(ast, rv.Elem(), "")
+}
+
+func newDecodeError(ast JsonAst, path string, format string, args ...interface{}) *DecodeError {
+    return &DecodeError{
+        Path:   path,
+        Line:   ast.Loc.lineNum,
+        Column: ast.Loc.position,
+        Msg:    fmt.Sprintf(format, args...),
+    }
+}
+
+func mismatch(ast JsonAst, path string, t reflect.Type) *DecodeError {
+    return newDecodeError(ast, path, "cannot decode %s into go value of type %s", kindOf(ast), t)
+}
+
+// kindOf names the json kind of the node for messages
+func kindOf(ast JsonAst) string {
+    switch ast.Typ {
+    case Object: return "object"
+    case Array:  return "array"
+    }
+    switch ast.LiteralAst.Typ {
+    case String:  return "string"
+    case Number:  return "number " + ast.LiteralAst.Val
+    case Boolean: return "bool"
+    default:      return "null"
+    }
+}
+
+// indirect walks down v allocating pointers as needed, until it gets to a non-pointer,
+// a JsonAst or a value implementing json.Unmarshaler or encoding.TextUnmarshaler
+func indirect(v reflect.Value, decodingNull bool) (json.Unmarshaler, encoding.TextUnmarshaler, reflect.Value) {
+    // a named non-pointer value may have pointer receiver methods
+    if v.Kind() != reflect.Ptr && v.Type().Name() != "" && v.CanAddr() {
+        v = v.Addr()
+    }
+
+    for {
+        if v.Kind() == reflect.Interface && !v.IsNil() {
+            var e = v.Elem()
+            if e.Kind() == reflect.Ptr && !e.IsNil() && (!decodingNull || e.Elem().Kind() == reflect.Ptr) {
+                v = e
+                continue
+            }
+        }
+        if v.Kind() != reflect.Ptr { break }
+        if decodingNull && v.CanSet() { break }
+        if v.IsNil() { v.Set(reflect.New(v.Type().Elem())) }
+        if v.Type().Elem() == astType { // the node is stored as is
+            v = v.Elem()
+            break
+        }
+        if v.Type().NumMethod() > 0 && v.CanInterface() {
+            if u, ok := v.Interface().(json.Unmarshaler); ok {
+                return u, nil, reflect.Value{}
+            }
+            if u, ok := v.Interface().(encoding.TextUnmarshaler); ok {
+                return nil, u, reflect.Value{}
+            }
+        }
+        v = v.Elem()
+    }
+
+    return nil, nil, v
+}
+
+func decodeValue(ast JsonAst, v reflect.Value, path string) error {
+    if ast.Typ == Literal && ast.LiteralAst.Typ == Null {
+        _, _, v = indirect(v, true)
+        if v.Type() == astType {
+            v.Set(reflect.ValueOf(ast))
+            return nil
+        }
+        switch v.Kind() {
+        case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+            v.Set(reflect.Zero(v.Type()))
+        }
+        return nil // null is a no-op for other kinds
+    }
+
+    u, tu, v := indirect(v, false)
+    if u != nil {
+        b, err := ast.MarshalJSON()
+        if err != nil {
+            return newDecodeError(ast, path, "%v", err)
+        }
+        if err = u.UnmarshalJSON(b); err != nil {
+            return newDecodeError(ast, path, "%v", err)
+        }
+        return nil
+    }
+    if v.Type() == astType {
+        v.Set(reflect.ValueOf(ast))
+        return nil
+    }
+    if tu != nil {
+        if ast.Typ != Literal || ast.LiteralAst.Typ != String {
+            return newDecodeError(ast, path, "cannot decode %s into encoding.TextUnmarshaler", kindOf(ast))
+        }
+        s, err := unquote(ast.LiteralAst.Val)
+        if err != nil {
+            return newDecodeError(ast, path, "%v", err)
+        }
+        if err = tu.UnmarshalText([]byte(s)); err != nil {
+            return newDecodeError(ast, path, "%v", err)
+        }
+        return nil
+    }
+
+    switch ast.Typ {
+    case Object: return decodeObject(ast, v, path)
+    case Array:  return decodeArray(ast, v, path)
+    default:     return decodeLiteral(ast, v, path)
+    }
+}
+
+func decodeObject(ast JsonAst, v reflect.Value, path string) error {
+    switch v.Kind() {
+    case reflect.Interface:
+        if v.NumMethod() != 0 {
+            return mismatch(ast, path, v.Type())
+        }
+        val, err := toInterface(ast, Float64Number, path)
+        if err != nil {
+            return err
+        }
+        v.Set(reflect.ValueOf(val))
+        return nil
+    case reflect.Map:
+        return decodeMap(ast, v, path)
+    case reflect.Struct:
+        return decodeStruct(ast, v, path)
+    default:
+        return mismatch(ast, path, v.Type())
+    }
+}
+
+func decodeMap(ast JsonAst, v reflect.Value, path string) error {
+    var t = v.Type()
+    var kt = t.Key()
+    switch kt.Kind() {
+    case reflect.String,
+        reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+    default:
+        if !reflect.PtrTo(kt).Implements(textUnmarshalerType) {
+            return mismatch(ast, path, t)
+        }
+    }
+    if v.IsNil() { v.Set(reflect.MakeMap(t)) }
+
+    for _, k := range objectKeys(ast) { // in document order, the last member wins
+        var member = ast.ObjectAst[k]
+        name, err := unquote(k)
+        if err != nil {
+            return newDecodeError(member, path, "%v", err)
+        }
+        var memberPath = path + "/" + escapePointerToken(name)
+
+        var kv reflect.Value
+        switch {
+        case reflect.PtrTo(kt).Implements(textUnmarshalerType):
+            kv = reflect.New(kt)
+            if err = kv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(name)); err != nil {
+                return newDecodeError(member, memberPath, "%v", err)
+            }
+            kv = kv.Elem()
+        case kt.Kind() == reflect.String:
+            kv = reflect.ValueOf(name).Convert(kt)
+        case kt.Kind() >= reflect.Int && kt.Kind() <= reflect.Int64:
+            n, err := strconv.ParseInt(name, 10, 64)
+            if err != nil || reflect.Zero(kt).OverflowInt(n) {
+                return newDecodeError(member, memberPath, "cannot decode key %s into go value of type %s", quote(name), kt)
+            }
+            kv = reflect.ValueOf(n).Convert(kt)
+        default:
+            n, err := strconv.ParseUint(name, 10, 64)
+            if err != nil || reflect.Zero(kt).OverflowUint(n) {
+                return newDecodeError(member, memberPath, "cannot decode key %s into go value of type %s", quote(name), kt)
+            }
+            kv = reflect.ValueOf(n).Convert(kt)
+        }
+
+        var ev = reflect.New(t.Elem()).Elem()
+        if err = decodeValue(member, ev, memberPath); err != nil {
+            return err
+        }
+        v.SetMapIndex(kv, ev)
+    }
+
+    return nil
+}
+
+func decodeStruct(ast JsonAst, v reflect.Value, path string) error {
+    var fields = cachedTypeFields(v.Type())
+
+    for _, k := range objectKeys(ast) { // in document order, the last member wins
+        var member = ast.ObjectAst[k]
+        name, err := unquote(k)
+        if err != nil {
+            return newDecodeError(member, path, "%v", err)
+        }
+        var memberPath = path + "/" + escapePointerToken(name)
+
+        var f *field
+        for i := range fields {
+            if fields[i].name == name {
+                f = &fields[i]
+                break
+            }
+        }
+        if f == nil {
+            for i := range fields {
+                if strings.EqualFold(fields[i].name, name) {
+                    f = &fields[i]
+                    break
+                }
+            }
+        }
+        if f == nil { continue } // unknown members are ignored
+
+        var fv = v
+        for i, idx := range f.index {
+            if i > 0 && fv.Kind() == reflect.Ptr {
+                if fv.IsNil() {
+                    if !fv.CanSet() {
+                        return newDecodeError(member, memberPath, "cannot set embedded pointer to unexported struct %s", fv.Type().Elem())
+                    }
+                    fv.Set(reflect.New(fv.Type().Elem()))
+                }
+                fv = fv.Elem()
+            }
+            fv = fv.Field(idx)
+        }
+
+        if f.quoted {
+            err = decodeQuoted(member, fv, memberPath)
+        } else {
+            err = decodeValue(member, fv, memberPath)
+        }
+        if err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// decodeQuoted decodes the literal written inside the json string of a field with the ,string option
+func decodeQuoted(ast JsonAst, v reflect.Value, path string) error {
+    if ast.Typ == Literal && ast.LiteralAst.Typ == Null {
+        return decodeValue(ast, v, path)
+    }
+    if ast.Typ != Literal || ast.LiteralAst.Typ != String {
+        return newDecodeError(ast, path, "invalid use of ,string struct tag, trying to decode %s into %s", kindOf(ast), v.Type())
+    }
+    s, err := unquote(ast.LiteralAst.Val)
+    if err != nil {
+        return newDecodeError(ast, path, "%v", err)
+    }
+    var jts, jerrs = tokenize(s)
+    if len(jerrs) != 0 || len(jts) != 1 || jts[0].Val != s {
+        return newDecodeError(ast, path, "invalid use of ,string struct tag, trying to decode %s into %s", quote(s), v.Type())
+    }
+    var inner = JsonAst{LiteralAst: literalAst(jts[0]), Typ: Literal, Loc: ast.Loc, End: ast.End}
+    return decodeValue(inner, v, path)
+}
+
+func decodeArray(ast JsonAst, v reflect.Value, path string) error {
+    switch v.Kind() {
+    case reflect.Interface:
+        if v.NumMethod() != 0 {
+            return mismatch(ast, path, v.Type())
+        }
+        val, err := toInterface(ast, Float64Number, path)
+        if err != nil {
+            return err
+        }
+        v.Set(reflect.ValueOf(val))
+        return nil
+    case reflect.Slice:
+        var n = len(ast.ArrayAst)
+        if v.IsNil() || v.Cap() < n {
+            v.Set(reflect.MakeSlice(v.Type(), n, n))
+        } else {
+            v.SetLen(n)
+        }
+    case reflect.Array:
+    default:
+        return mismatch(ast, path, v.Type())
+    }
+
+    for i, elem := range ast.ArrayAst {
+        if i >= v.Len() { break } // extra elements are dropped for go arrays
+        var ev = v.Index(i)
+        ev.Set(reflect.Zero(ev.Type()))
+        if err := decodeValue(elem, ev, path+"/"+strconv.Itoa(i)); err != nil {
+            return err
+        }
+    }
+    for i := len(ast.ArrayAst); i < v.Len(); i++ {
+        v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+    }
+
+    return nil
+}
+
+func decodeLiteral(ast JsonAst, v reflect.Value, path string) error {
+    var raw = ast.LiteralAst.Val
+
+    switch ast.LiteralAst.Typ {
+    case Boolean:
+        var b = raw == "true"
+        switch {
+        case v.Kind() == reflect.Bool:
+            v.SetBool(b)
+        case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+            v.Set(reflect.ValueOf(b))
+        default:
+            return mismatch(ast, path, v.Type())
+        }
+    case String:
+        s, err := unquote(raw)
+        if err != nil {
+            return newDecodeError(ast, path, "%v", err)
+        }
+        switch {
+        case v.Type() == numberType:
+            if !isNumberLiteral(s) {
+                return newDecodeError(ast, path, "invalid number literal %s", quote(s))
+            }
+            v.SetString(s)
+        case v.Kind() == reflect.String:
+            v.SetString(s)
+        case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+            b, err := base64.StdEncoding.DecodeString(s)
+            if err != nil {
+                return newDecodeError(ast, path, "%v", err)
+            }
+            v.SetBytes(b)
+        case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+            v.Set(reflect.ValueOf(s))
+        default:
+            return mismatch(ast, path, v.Type())
+        }
+    case Number:
+        switch v.Kind() {
+        case reflect.Interface:
+            if v.NumMethod() != 0 {
+                return mismatch(ast, path, v.Type())
+            }
+            f, err := strconv.ParseFloat(raw, 64)
+            if err != nil {
+                return newDecodeError(ast, path, "number %s overflows float64", raw)
+            }
+            v.Set(reflect.ValueOf(f))
+        case reflect.String:
+            if v.Type() != numberType {
+                return mismatch(ast, path, v.Type())
+            }
+            v.SetString(raw)
+        case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+            n, err := strconv.ParseInt(raw, 10, 64)
+            if err != nil || v.OverflowInt(n) {
+                return mismatch(ast, path, v.Type())
+            }
+            v.SetInt(n)
+        case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+            n, err := strconv.ParseUint(raw, 10, 64)
+            if err != nil || v.OverflowUint(n) {
+                return mismatch(ast, path, v.Type())
+            }
+            v.SetUint(n)
+        case reflect.Float32, reflect.Float64:
+            f, err := strconv.ParseFloat(raw, v.Type().Bits())
+            if err != nil || v.OverflowFloat(f) {
+                return mismatch(ast, path, v.Type())
+            }
+            v.SetFloat(f)
+        default:
+            return mismatch(ast, path, v.Type())
+        }
+    default:
+        return mismatch(ast, path, v.Type())
+    }
+
+    return nil
+}
+
+// isNumberLiteral tells if s is a json number and nothing else
+func isNumberLiteral(s string) bool {
+    var jts, jerrs = tokenize(s)
+    return len(jerrs) == 0 && len(jts) == 1 && jts[0].Typ == Number && jts[0].Val == s
+}
+
+// escapePointerToken escapes a member name as a json pointer reference token (RFC 6901)
+func escapePointerToken(name string) string {
+    return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
@@ -0,0 +1,200 @@
+package json2ast
+
+import (
+    "encoding/json"
+    "reflect"
+    "testing"
+    "time"
+)
+
+type DecodeInner struct {
+    City  string `json:"city"`
+    Zip   *int   `json:"zip,omitempty"`
+}
+
+type DecodeEmbedded struct {
+    ID    int64  `json:"id"`
+    Shade string
+}
+
+type decodeSample struct {
+    DecodeEmbedded
+    *DecodeInner
+    Name     string                 `json:"name"`
+    Tags     []string               `json:"tags,omitempty"`
+    Scores   map[string]float64     `json:"scores"`
+    ByID     map[int]bool           `json:"by_id"`
+    Pair     [2]uint8               `json:"pair"`
+    Any      interface{}            `json:"any"`
+    Ptr      **string               `json:"ptr"`
+    When     time.Time              `json:"when"`
+    Num      json.Number            `json:"num"`
+    Ignored  string                 `json:"-"`
+    Null     *DecodeInner           `json:"null"`
+    Nested   []map[string][]int     `json:"nested"`
+}
+
+func TestDecode(t *testing.T) {
+    validTests := []string {
+        `{
+            "id": 42, "shade": "blue", "city": "Paris", "zip": 75001,
+            "name": "sample", "tags": ["a", "b"], "scores": {"x": 1.5, "y": -2e3},
+            "by_id": {"1": true, "-7": false}, "pair": [1, 2, 3],
+            "any": {"k": [1, "two", null, true]}, "ptr": "deep",
+            "when": "2021-06-01T12:00:00Z", "num": 12.50, "Ignored": "no",
+            "null": null, "nested": [{"a": [1, 2]}, {}], "unknown": {"x": 1}
+        }`,
+        `{"NAME": "case insensitive", "pair": [7]}`,
+        `{}`,
+    }
+
+    for _, vt := range validTests {
+        ast, jerrs := Parser(vt)
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed", vt)
+        }
+
+        var got, want decodeSample
+        if err := Decode(ast, &got); err != nil {
+            t.Fatalf("decode `%s` failed: %v", vt, err)
+        }
+        if err := json.Unmarshal([]byte(vt), &want); err != nil {
+            t.Fatalf("unmarshal `%s` failed: %v", vt, err)
+        }
+        if !reflect.DeepEqual(got, want) {
+            t.Fatalf("decode `%s`:\n got %+v\nwant %+v", vt, got, want)
+        }
+    }
+}
+
+type decodeStrings struct {
+    N     int      `json:"n,string"`
+    F     *float64 `json:"f,string"`
+    B     bool     `json:",string"`
+    S     string   `json:"s,string"`
+    Num   json.Number
+    Bytes []byte
+}
+
+// the ,string option, strings into json.Number and base64 into []byte, as encoding/json does
+func TestDecodeStrings(t *testing.T) {
+    validTests := []string {
+        `{"n": "-12", "f": "1.5e3", "B": "true", "s": "\"x\"", "Num": "12.50", "Bytes": "aGVsbG8="}`,
+        `{"n": null, "f": null, "Num": 3, "Bytes": [1, 2]}`,
+        `{"Bytes": ""}`,
+    }
+    for _, vt := range validTests {
+        ast, _ := Parser(vt)
+        var got, want decodeStrings
+        if err := Decode(ast, &got); err != nil {
+            t.Fatalf("decode `%s` failed: %v", vt, err)
+        }
+        if err := json.Unmarshal([]byte(vt), &want); err != nil {
+            t.Fatalf("unmarshal `%s` failed: %v", vt, err)
+        }
+        if !reflect.DeepEqual(got, want) {
+            t.Fatalf("decode `%s`:\n got %+v\nwant %+v", vt, got, want)
+        }
+    }
+
+    invalidTests := []struct {
+        json string
+        path string
+    }{
+        {`{"n": 12}`, "/n"},
+        {`{"n": "x"}`, "/n"},
+        {`{"n": " 1"}`, "/n"},
+        {`{"s": "x"}`, "/s"},
+        {`{"B": "1"}`, "/B"},
+        {`{"Num": "1e"}`, "/Num"},
+        {`{"Bytes": "%%"}`, "/Bytes"},
+    }
+    for _, ivt := range invalidTests {
+        ast, _ := Parser(ivt.json)
+        var v decodeStrings
+        err := Decode(ast, &v)
+        if derr, ok := err.(*DecodeError); !ok || derr.Path != ivt.path {
+            t.Errorf("decode `%s` gives %v, want an error at %s", ivt.json, err, ivt.path)
+        }
+        if json.Unmarshal([]byte(ivt.json), &v) == nil {
+            t.Errorf("unmarshal `%s` succeeds", ivt.json)
+        }
+    }
+}
+
+// members are decoded in document order, the last one matching a field wins
+func TestDecodeMemberOrder(t *testing.T) {
+    var tests = []string {
+        `{"a": 1, "A": 2}`,
+        `{"A": 1, "a": 2}`,
+        `{"name": "x", "NAME": "y", "Name": "z"}`,
+        `{"name": {}, "Name": "z", "nAme": []}`,
+    }
+    for _, test := range tests {
+        ast, _ := Parser(test)
+        for i := 0; i < 20; i++ {
+            var got, want struct {
+                A    int
+                Name interface{} `json:"name"`
+            }
+            if err := Decode(ast, &got); err != nil {
+                t.Fatalf("decode `%s` failed: %v", test, err)
+            }
+            _ = json.Unmarshal([]byte(test), &want)
+            if !reflect.DeepEqual(got, want) {
+                t.Fatalf("decode `%s` gives %+v, want %+v", test, got, want)
+            }
+        }
+    }
+
+    // the error of the first member which fails
+    ast, _ := Parser(`{"id": "x", "name": 1, "pair": "y"}`)
+    for i := 0; i < 20; i++ {
+        var v decodeSample
+        if err := Decode(ast, &v); err == nil || err.(*DecodeError).Path != "/id" {
+            t.Fatalf("decode gives %v, want the error at /id", err)
+        }
+    }
+}
+
+func TestDecodeError(t *testing.T) {
+    invalidTests := []struct {
+        json   string
+        path   string
+        line   int
+        column int
+    }{
+        {`{"name": 1}`, "/name", 1, 10},
+        {`{"tags": ["a",
+                    {"b": 2}]}`, "/tags/1", 2, 21},
+        {`{"scores": {"a/b": "x"}}`, "/scores/a~1b", 1, 20},
+        {`{"by_id": {"k": true}}`, "/by_id/k", 1, 17},
+        {`{"pair": [1, 256]}`, "/pair/1", 1, 14},
+        {`{"id": 1.5}`, "/id", 1, 8},
+        {`{"when": "yesterday"}`, "/when", 1, 10},
+        {`{"num": "1x"}`, "/num", 1, 9},
+        {`[]`, "", 1, 1},
+    }
+
+    for _, ivt := range invalidTests {
+        ast, jerrs := Parser(ivt.json)
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed", ivt.json)
+        }
+
+        var v decodeSample
+        err := Decode(ast, &v)
+        derr, ok := err.(*DecodeError)
+        if !ok {
+            t.Fatalf("decode `%s` should fail with *DecodeError, got %v", ivt.json, err)
+        }
+        if derr.Path != ivt.path || derr.Line != ivt.line || derr.Column != ivt.column {
+            t.Fatalf("decode `%s`: got error %v, want at %s [%d, %d]", ivt.json, derr, ivt.path, ivt.line, ivt.column)
+        }
+    }
+
+    ast, _ := Parser(`1`)
+    if err := Decode(ast, decodeSample{}); err == nil {
+        t.Fatal("decode into non-pointer should fail")
+    }
+}
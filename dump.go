@@ -0,0 +1,150 @@
+package json2ast
+
+import (
+    "fmt"
+    "strings"
+)
+
+// kind names the node kind in dumps: object, array, string, number, boolean or null
+func (ast JsonAst) kind() string {
+    if ast.Typ == Literal {
+        return strings.ToLower(ast.LiteralAst.Typ.String())
+    }
+    if ast.Typ == Array {
+        return "array"
+    }
+    return "object"
+}
+
+func (ast JsonAst) span() string {
+    return fmt.Sprintf("%d:%d-%d:%d", ast.Loc.lineNum, ast.Loc.position, ast.End.lineNum, ast.End.position)
+}
+
+// Dump prints the ast as an indented tree, one node per line with its kind,
+// span (line:column of the first character and just past the last one) and value:
+//
+//     object 1:1-1:19
+//       "a": array 1:7-1:18
+//         number 1:8-1:9 1
+//         string 1:11-1:17 "text"
+func Dump(ast JsonAst) string {
+    var sb strings.Builder
+    dumpTree(&sb, ast, "", 0)
+    return sb.String()
+}
+
+func dumpTree(sb *strings.Builder, ast JsonAst, key string, depth int) {
+    sb.WriteString(strings.Repeat("  ", depth))
+    sb.WriteString(key)
+    sb.WriteString(ast.kind())
+    sb.WriteByte(' ')
+    sb.WriteString(ast.span())
+
+    switch ast.Typ {
+    case Object:
+        sb.WriteByte('\n')
+        for _, k := range objectKeys(ast) {
+            dumpTree(sb, ast.ObjectAst[k], k+": ", depth+1)
+        }
+    case Array:
+        sb.WriteByte('\n')
+        for _, v := range ast.ArrayAst {
+            dumpTree(sb, v, "", depth+1)
+        }
+    default:
+        sb.WriteByte(' ')
+        sb.WriteString(ast.LiteralAst.Val)
+        sb.WriteByte('\n')
+    }
+}
+
+// DumpJSON dumps the ast as indented json, every node is an object with
+// "kind", "start" and "end" ([line, column]) and "members", "elements" or "text"
+func DumpJSON(ast JsonAst) []byte {
+    return FormatIndent(dumpNode(ast), "  ")
+}
+
+func dumpNode(ast JsonAst) JsonAst {
+    var node = JsonAst{ObjectAst: map[string]JsonAst{}, Typ: Object}
+    var add = func(name string, value JsonAst) {
+        node.ObjectAst[quote(name)] = value
+        node.ObjectKeys = append(node.ObjectKeys, quote(name))
+    }
+    var position = func(loc location) JsonAst {
+        return JsonAst{
+            ArrayAst: []JsonAst{
+                literalNode(Number, fmt.Sprint(loc.lineNum)),
+                literalNode(Number, fmt.Sprint(loc.position)),
+            },
+            Typ: Array,
+        }
+    }
+
+    add("kind", literalNode(String, quote(ast.kind())))
+    add("start", position(ast.Loc))
+    add("end", position(ast.End))
+
+    switch ast.Typ {
+    case Object:
+        var members = JsonAst{ArrayAst: []JsonAst{}, Typ: Array}
+        for _, k := range objectKeys(ast) {
+            var m = JsonAst{ObjectAst: map[string]JsonAst{}, Typ: Object, ObjectKeys: []string{`"key"`, `"value"`}}
+            m.ObjectAst[`"key"`] = literalNode(String, quote(k))
+            m.ObjectAst[`"value"`] = dumpNode(ast.ObjectAst[k])
+            members.ArrayAst = append(members.ArrayAst, m)
+        }
+        add("members", members)
+    case Array:
+        var elements = JsonAst{ArrayAst: []JsonAst{}, Typ: Array}
+        for _, v := range ast.ArrayAst {
+            elements.ArrayAst = append(elements.ArrayAst, dumpNode(v))
+        }
+        add("elements", elements)
+    default:
+        add("text", literalNode(String, quote(ast.LiteralAst.Val)))
+    }
+
+    return node
+}
+
+// DumpSexpr dumps the ast as an s-expression, one node per line:
+//
+//     (object 1:1-1:19
+//       (member "a" (array 1:7-1:18
+//         (number 1:8-1:9 1)
+//         (string 1:11-1:17 "text"))))
+func DumpSexpr(ast JsonAst) string {
+    var sb strings.Builder
+    dumpSexpr(&sb, ast, 0)
+    sb.WriteByte('\n')
+    return sb.String()
+}
+
+func dumpSexpr(sb *strings.Builder, ast JsonAst, depth int) {
+    sb.WriteByte('(')
+    sb.WriteString(ast.kind())
+    sb.WriteByte(' ')
+    sb.WriteString(ast.span())
+
+    var pad = "\n" + strings.Repeat("  ", depth+1)
+    switch ast.Typ {
+    case Object:
+        for _, k := range objectKeys(ast) {
+            sb.WriteString(pad)
+            sb.WriteString("(member ")
+            sb.WriteString(k)
+            sb.WriteByte(' ')
+            dumpSexpr(sb, ast.ObjectAst[k], depth+1)
+            sb.WriteByte(')')
+        }
+    case Array:
+        for _, v := range ast.ArrayAst {
+            sb.WriteString(pad)
+            dumpSexpr(sb, v, depth+1)
+        }
+    default:
+        sb.WriteByte(' ')
+        sb.WriteString(ast.LiteralAst.Val)
+    }
+    sb.WriteByte(')')
+}
@@ -0,0 +1,73 @@
+package json2ast
+
+import (
+    "flag"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestDumpGolden dumps every testdata/dump/*.json as .tree, .dump (json) and .sexpr.
+// for invalid inputs only the errors are listed, as the ast command does
+func TestDumpGolden(t *testing.T) {
+    inputs, err := filepath.Glob(filepath.Join("testdata", "dump", "*.json"))
+    if err != nil || len(inputs) == 0 {
+        t.Fatalf("no inputs in testdata/dump: %v", err)
+    }
+
+    for _, in := range inputs {
+        source, err := os.ReadFile(in)
+        if err != nil {
+            t.Fatal(err)
+        }
+        ast, jerrs := Parser(string(source))
+
+        var errs strings.Builder
+        for _, jerr := range jerrs {
+            errs.WriteString(jerr.Error())
+            errs.WriteByte('\n')
+        }
+
+        var base = strings.TrimSuffix(in, ".json")
+        if len(jerrs) != 0 {
+            for _, ext := range []string{".tree", ".dump", ".sexpr"} {
+                checkGolden(t, base+ext, errs.String())
+            }
+            continue
+        }
+        checkGolden(t, base+".tree", Dump(ast))
+        checkGolden(t, base+".dump", string(DumpJSON(ast))+"\n")
+        checkGolden(t, base+".sexpr", DumpSexpr(ast))
+    }
+}
+
+func checkGolden(t *testing.T, path string, got string) {
+    if *update {
+        if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+            t.Fatal(err)
+        }
+        return
+    }
+
+    want, err := os.ReadFile(path)
+    if err != nil {
+        t.Fatalf("%v, run `go test -run %s -update` to create it", err, t.Name())
+    }
+    if got != string(want) {
+        t.Errorf("%s differs from the golden file:\n%s", path, got)
+    }
+}
+
+func TestDumpSpans(t *testing.T) {
+    ast, _ := Parser("{\"a\": [1, \"é\"]}")
+    var want = "object 1:1-1:16\n" +
+        "  \"a\": array 1:7-1:15\n" +
+        "    number 1:8-1:9 1\n" +
+        "    string 1:11-1:14 \"é\"\n"
+    if got := Dump(ast); got != want {
+        t.Fatalf("Dump gives\n%s\nwant\n%s", got, want)
+    }
+}
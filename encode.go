@@ -0,0 +1,293 @@
+package json2ast
+
+import (
+    "encoding"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "reflect"
+    "sort"
+    "strconv"
+)
+
+var (
+    marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+    textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// FromValue builds a JsonAst from the go value v following the rules of encoding/json.Marshal:
+// `json` struct tags with omitempty and string, embedded structs, maps, slices, scalars,
+// json.Marshaler and encoding.TextMarshaler are honoured
+func FromValue(v interface{}) (JsonAst, error) {
+    var e encodeState
+    return e.encodeValue(reflect.ValueOf(v))
+}
+
+// encodeState finds the cycles of a value as encoding/json does: past
+// startDetectingCyclesAfter levels of pointers, maps and slices, those on
+// the way down are remembered, and meeting one of them again is an error
+type encodeState struct {
+    ptrLevel uint
+    ptrSeen  map[cycleKey]struct{}
+}
+
+const startDetectingCyclesAfter = 1000
+
+type cycleKey struct {
+    ptr uintptr
+    len int // of a slice, a subslice is not a cycle
+    typ reflect.Type
+}
+
+// enter goes down a pointer, map or slice, leave comes back up
+func (e *encodeState) enter(v reflect.Value) error {
+    if e.ptrLevel++; e.ptrLevel <= startDetectingCyclesAfter {
+        return nil
+    }
+    var key = e.key(v)
+    if _, ok := e.ptrSeen[key]; ok {
+        return fmt.Errorf("encountered a cycle via %s", v.Type())
+    }
+    if e.ptrSeen == nil { e.ptrSeen = map[cycleKey]struct{}{} }
+    e.ptrSeen[key] = struct{}{}
+    return nil
+}
+
+func (e *encodeState) leave(v reflect.Value) {
+    if e.ptrLevel > startDetectingCyclesAfter { delete(e.ptrSeen, e.key(v)) }
+    e.ptrLevel--
+}
+
+func (e *encodeState) key(v reflect.Value) cycleKey {
+    var key = cycleKey{ptr: v.Pointer(), typ: v.Type()}
+    if v.Kind() == reflect.Slice { key.len = v.Len() }
+    return key
+}
+
+func literalNode(typ TokenType, val string) JsonAst {
+    return JsonAst{
+        LiteralAst: literalAst{Typ: typ, Val: val},
+        Typ:        Literal,
+    }
+}
+
+var nullNode = literalNode(Null, "null")
+
+func (e *encodeState) encodeValue(v reflect.Value) (JsonAst, error) {
+    if !v.IsValid() {
+        return nullNode, nil
+    }
+    if v.Type() == astType {
+        return v.Interface().(JsonAst), nil
+    }
+
+    // pointer receiver methods are only reachable from addressable values
+    if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(marshalerType) {
+        v = v.Addr()
+    }
+    if v.Kind() != reflect.Interface && v.Type().Implements(marshalerType) {
+        if v.Kind() == reflect.Ptr && v.IsNil() {
+            return nullNode, nil
+        }
+        b, err := v.Interface().(json.Marshaler).MarshalJSON()
+        if err != nil {
+            return JsonAst{}, fmt.Errorf("MarshalJSON of %s: %v", v.Type(), err)
+        }
+        ast, jerrs := Parser(string(b))
+        if len(jerrs) != 0 {
+            return JsonAst{}, fmt.Errorf("MarshalJSON of %s returned invalid json: %v", v.Type(), jerrs[0])
+        }
+        return ast, nil
+    }
+
+    if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(textMarshalerType) {
+        v = v.Addr()
+    }
+    if v.Kind() != reflect.Interface && v.Type().Implements(textMarshalerType) {
+        if v.Kind() == reflect.Ptr && v.IsNil() {
+            return nullNode, nil
+        }
+        b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+        if err != nil {
+            return JsonAst{}, fmt.Errorf("MarshalText of %s: %v", v.Type(), err)
+        }
+        return literalNode(String, quote(string(b))), nil
+    }
+
+    switch v.Kind() {
+    case reflect.Bool:
+        return literalNode(Boolean, strconv.FormatBool(v.Bool())), nil
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        return literalNode(Number, strconv.FormatInt(v.Int(), 10)), nil
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+        return literalNode(Number, strconv.FormatUint(v.Uint(), 10)), nil
+    case reflect.Float32, reflect.Float64:
+        s, err := formatFloat(v.Float(), v.Type().Bits())
+        if err != nil {
+            return JsonAst{}, err
+        }
+        return literalNode(Number, s), nil
+    case reflect.String:
+        if v.Type() == numberType {
+            var s = v.String()
+            if s == "" { s = "0" }
+            if jts, jerrs := tokenize(s); len(jerrs) != 0 || len(jts) != 1 || jts[0].Typ != Number {
+                return JsonAst{}, fmt.Errorf("invalid number literal %q", s)
+            }
+            return literalNode(Number, s), nil
+        }
+        return literalNode(String, quote(v.String())), nil
+    case reflect.Interface:
+        if v.IsNil() {
+            return nullNode, nil
+        }
+        return e.encodeValue(v.Elem())
+    case reflect.Ptr:
+        if v.IsNil() {
+            return nullNode, nil
+        }
+        if err := e.enter(v); err != nil {
+            return JsonAst{}, err
+        }
+        defer e.leave(v)
+        return e.encodeValue(v.Elem())
+    case reflect.Struct:
+        return e.encodeStruct(v)
+    case reflect.Map:
+        if v.IsNil() {
+            return nullNode, nil
+        }
+        if err := e.enter(v); err != nil {
+            return JsonAst{}, err
+        }
+        defer e.leave(v)
+        return e.encodeMap(v)
+    case reflect.Slice:
+        if v.IsNil() {
+            return nullNode, nil
+        }
+        if v.Type().Elem().Kind() == reflect.Uint8 && !reflect.PtrTo(v.Type().Elem()).Implements(marshalerType) {
+            return literalNode(String, quote(base64.StdEncoding.EncodeToString(v.Bytes()))), nil
+        }
+        if err := e.enter(v); err != nil {
+            return JsonAst{}, err
+        }
+        defer e.leave(v)
+        return e.encodeArray(v)
+    case reflect.Array:
+        return e.encodeArray(v)
+    default:
+        return JsonAst{}, fmt.Errorf("unsupported type %s", v.Type())
+    }
+}
+
+func (e *encodeState) encodeStruct(v reflect.Value) (JsonAst, error) {
+    var ast = JsonAst{ObjectAst: map[string]JsonAst{}, Typ: Object}
+
+fieldLoop:
+    for _, f := range cachedTypeFields(v.Type()) {
+        var fv = v
+        for i, idx := range f.index {
+            if i > 0 && fv.Kind() == reflect.Ptr {
+                if fv.IsNil() { continue fieldLoop } // promoted through a nil embedded pointer
+                fv = fv.Elem()
+            }
+            fv = fv.Field(idx)
+        }
+        if f.omitEmpty && isEmptyValue(fv) { continue }
+
+        member, err := e.encodeValue(fv)
+        if err != nil {
+            return JsonAst{}, err
+        }
+        if f.quoted && member.Typ == Literal && member.LiteralAst.Typ != Null && !isMarshaler(f.typ) {
+            member = literalNode(String, quote(member.LiteralAst.Val)) // the ,string option
+        }
+        ast.ObjectAst[quote(f.name)] = member
+        ast.ObjectKeys = append(ast.ObjectKeys, quote(f.name))
+    }
+
+    return ast, nil
+}
+
+func (e *encodeState) encodeMap(v reflect.Value) (JsonAst, error) {
+    var ast = JsonAst{ObjectAst: make(map[string]JsonAst, v.Len()), Typ: Object}
+    var names = make([]string, 0, v.Len())
+    var iter = v.MapRange()
+    for iter.Next() {
+        var k = iter.Key()
+        var name string
+        switch {
+        case k.Kind() == reflect.String:
+            name = k.String()
+        case k.Type().Implements(textMarshalerType):
+            if k.Kind() == reflect.Ptr && k.IsNil() {
+                name = ""
+                break
+            }
+            b, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+            if err != nil {
+                return JsonAst{}, fmt.Errorf("MarshalText of %s: %v", k.Type(), err)
+            }
+            name = string(b)
+        case k.Kind() >= reflect.Int && k.Kind() <= reflect.Int64:
+            name = strconv.FormatInt(k.Int(), 10)
+        case k.Kind() >= reflect.Uint && k.Kind() <= reflect.Uintptr:
+            name = strconv.FormatUint(k.Uint(), 10)
+        default:
+            return JsonAst{}, fmt.Errorf("unsupported map key type %s", k.Type())
+        }
+
+        member, err := e.encodeValue(iter.Value())
+        if err != nil {
+            return JsonAst{}, err
+        }
+        ast.ObjectAst[quote(name)] = member
+        names = append(names, name)
+    }
+
+    sort.Strings(names) // encoding/json writes the members of a map sorted by name
+    ast.ObjectKeys = make([]string, len(names))
+    for i, name := range names {
+        ast.ObjectKeys[i] = quote(name)
+    }
+
+    return ast, nil
+}
+
+// isMarshaler tells if t, or the type it points to, marshals itself: the ,string option is then ignored
+func isMarshaler(t reflect.Type) bool {
+    if t.Kind() == reflect.Ptr { t = t.Elem() }
+    var pt = reflect.PtrTo(t)
+    return pt.Implements(marshalerType) || pt.Implements(textMarshalerType)
+}
+
+func (e *encodeState) encodeArray(v reflect.Value) (JsonAst, error) {
+    var ast = JsonAst{ArrayAst: make([]JsonAst, 0, v.Len()), Typ: Array}
+    for i := 0; i < v.Len(); i++ {
+        elem, err := e.encodeValue(v.Index(i))
+        if err != nil {
+            return JsonAst{}, err
+        }
+        ast.ArrayAst = append(ast.ArrayAst, elem)
+    }
+    return ast, nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+    switch v.Kind() {
+    case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+        return v.Len() == 0
+    case reflect.Bool:
+        return !v.Bool()
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+        return v.Int() == 0
+    case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+        return v.Uint() == 0
+    case reflect.Float32, reflect.Float64:
+        return v.Float() == 0
+    case reflect.Interface, reflect.Ptr:
+        return v.IsNil()
+    }
+    return false
+}
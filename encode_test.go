@@ -0,0 +1,158 @@
+package json2ast
+
+import (
+    "encoding/json"
+    "math"
+    "reflect"
+    "strings"
+    "testing"
+    "time"
+)
+
+type encodeMarshaler struct {
+    N int
+}
+
+func (em encodeMarshaler) MarshalJSON() ([]byte, error) {
+    return []byte(`{"marshaled": [true, null]}`), nil
+}
+
+type encodeSample struct {
+    decodeSample
+    Bytes   []byte                      `json:"bytes"`
+    Empty   string                      `json:"empty,omitempty"`
+    Nil     []int                       `json:"nil"`
+    Keys    map[time.Time]int           `json:"keys"`
+    Custom  encodeMarshaler             `json:"custom"`
+    CustomP *encodeMarshaler            `json:"custom_p"`
+    Floats  []float32                   `json:"floats"`
+}
+
+func TestFromValue(t *testing.T) {
+    var zip = 75001
+    var s = "deep"
+    var ps = &s
+    var when = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+    validTests := []interface{} {
+        nil,
+        "string \"quoted\" \u2028 <tag>",
+        -12.5e-10,
+        uint8(200),
+        []interface{}{1, "two", nil, true, map[string]int{"x": 1}},
+        map[int]string{-1: "neg", 2: "pos"},
+        encodeSample{
+            decodeSample: decodeSample{
+                DecodeEmbedded: DecodeEmbedded{ID: 42, Shade: "blue"},
+                DecodeInner:    &DecodeInner{City: "Paris", Zip: &zip},
+                Name:           "sample",
+                Scores:         map[string]float64{"x": 1.5, "y": 1e21},
+                Pair:           [2]uint8{1, 2},
+                Any:            map[string]interface{}{"k": []interface{}{1.0, "two"}},
+                Ptr:            &ps,
+                When:           when,
+                Num:            "12.50",
+                Ignored:        "no",
+            },
+            Bytes:   []byte("hello"),
+            Keys:    map[time.Time]int{when: 1},
+            Custom:  encodeMarshaler{1},
+            CustomP: &encodeMarshaler{2},
+            Floats:  []float32{0.1, 3.4e38},
+        },
+        encodeSample{},
+    }
+
+    for _, vt := range validTests {
+        ast, err := FromValue(vt)
+        if err != nil {
+            t.Fatalf("build AST for %#v failed: %v", vt, err)
+        }
+
+        b, err := json.Marshal(vt)
+        if err != nil {
+            t.Fatalf("marshal %#v failed: %v", vt, err)
+        }
+        want, jerrs := Parser(string(b))
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed", b)
+        }
+
+        gotObj, err := ToInterface(ast, Float64Number)
+        if err != nil {
+            t.Fatalf("AST of %#v is invalid: %v", vt, err)
+        }
+        wantObj, _ := ToInterface(want, Float64Number)
+        if !reflect.DeepEqual(gotObj, wantObj) {
+            t.Fatalf("AST of %#v:\n got %v\nwant %v", vt, gotObj, wantObj)
+        }
+    }
+
+    invalidTests := []interface{} {
+        math.NaN(),
+        map[[2]int]int{{1, 2}: 3},
+        make(chan int),
+        json.Number("1x"),
+    }
+    for _, ivt := range invalidTests {
+        if _, err := FromValue(ivt); err == nil {
+            t.Fatalf("build AST for %#v should fail", ivt)
+        }
+    }
+}
+
+// the ,string option and the order of map members give the same bytes as encoding/json
+func TestFromValueStrings(t *testing.T) {
+    var f = 1.5e3
+    var when = time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+    validTests := []interface{} {
+        decodeStrings{N: -12, F: &f, B: true, S: `"x"`, Num: "12.50", Bytes: []byte("hello")},
+        decodeStrings{},
+        struct {
+            When   time.Time        `json:",string"`
+            Custom *encodeMarshaler `json:",string"`
+            M      map[string]int   `json:",string"`
+        }{when, &encodeMarshaler{1}, map[string]int{"b": 2, "a\"": 1, "a#": 3, "": 0}},
+        map[int]bool{10: true, 9: false, -1: true},
+    }
+    for _, vt := range validTests {
+        ast, err := FromValue(vt)
+        if err != nil {
+            t.Fatalf("build AST for %#v failed: %v", vt, err)
+        }
+        got, _ := ast.MarshalJSON()
+        want, _ := json.Marshal(vt)
+        if string(got) != string(want) {
+            t.Errorf("AST of %#v:\n got %s\nwant %s", vt, got, want)
+        }
+    }
+}
+
+type cycleNode struct {
+    Next *cycleNode
+}
+
+// self-referencing values are errors, not a stack overflow
+func TestFromValueCycles(t *testing.T) {
+    var n = &cycleNode{}
+    n.Next = n
+    var m = map[string]interface{}{}
+    m["m"] = m
+    var s = []interface{}{nil}
+    s[0] = s
+    for _, v := range []interface{}{n, m, s} {
+        if _, err := FromValue(v); err == nil || !strings.Contains(err.Error(), "cycle") {
+            t.Errorf("%T with a cycle gives %v", v, err)
+        }
+    }
+
+    // a long chain without a cycle is fine
+    var chain = &cycleNode{}
+    for i := 0; i < 2*startDetectingCyclesAfter; i++ {
+        chain = &cycleNode{Next: chain}
+    }
+    if _, err := FromValue(chain); err != nil {
+        t.Errorf("a chain gives %v", err)
+    }
+}
@@ -22,6 +22,21 @@ const (
     CommaOrClosingBraceExpected
     CommaOrClosingBracketExpected
     EndOfJsonExpected
+
+    NumberOutOfRange
+
+    LeadingZero
+    LeadingPlus
+    NonASCIIDigit
+
+    InvalidUTF8
+    LoneSurrogate
+
+    TooLarge
+    TooManyTokens
+    StringTooLong
+    TooDeep
+    TooManyMembers
 )
 
 var descriptions = map[ErrorType]string {
@@ -42,6 +57,21 @@ var descriptions = map[ErrorType]string {
     CommaOrClosingBraceExpected: "CommaOrClosingBraceExpected",
     CommaOrClosingBracketExpected: "CommaOrClosingBracketExpected",
     EndOfJsonExpected: "EndOfJsonExpected",
+
+    NumberOutOfRange: "NumberOutOfRange",
+
+    LeadingZero: "LeadingZero",
+    LeadingPlus: "LeadingPlus",
+    NonASCIIDigit: "NonASCIIDigit",
+
+    InvalidUTF8: "InvalidUTF8",
+    LoneSurrogate: "LoneSurrogate",
+
+    TooLarge: "TooLarge",
+    TooManyTokens: "TooManyTokens",
+    StringTooLong: "StringTooLong",
+    TooDeep: "TooDeep",
+    TooManyMembers: "TooManyMembers",
 }
 
 type location struct {
@@ -56,4 +86,30 @@ type jsonError struct {
 
 func (jerr jsonError) Error() string {
     return fmt.Sprintf("[%d, %d], type: %s", jerr.loc.lineNum, jerr.loc.position, descriptions[jerr.typ])
-}
\ No newline at end of file
+}
+
+func (typ ErrorType) String() string {
+    return descriptions[typ]
+}
+
+// Line is the 1-based line number, -1 for the end of input
+func (loc location) Line() int {
+    return loc.lineNum
+}
+
+// Column is the 1-based column in runes, -1 for the end of input
+func (loc location) Column() int {
+    return loc.position
+}
+
+func (jerr jsonError) Type() ErrorType {
+    return jerr.typ
+}
+
+func (jerr jsonError) Line() int {
+    return jerr.loc.lineNum
+}
+
+func (jerr jsonError) Column() int {
+    return jerr.loc.position
+}
@@ -0,0 +1,100 @@
+package json2ast
+
+// EventType is the type of an Event
+type EventType uint8
+
+const (
+    StartObject EventType = iota // at {
+    EndObject                    // at }, after StartObject
+    StartArray                   // at [
+    EndArray                     // at ], after StartArray
+    Key                          // the key of a member
+    Value                        // a literal
+    SyntaxError                  // an error of the lexer or the parser, parsing goes on after it
+)
+
+// Event is what ParseEvents tells a Handler, Loc and End span its token
+type Event struct {
+    Typ         EventType
+    Val         string    // text of Key and Value as written in the source, strings with their quotes
+    LiteralType TokenType // of Value
+    Err         jsonError // of SyntaxError
+    Loc         location
+    End         location
+}
+
+// Signal is what a Handler answers to an Event
+type Signal uint8
+
+const (
+    Continue    Signal = iota
+    SkipSubtree          // at StartObject, StartArray or Key: no event for the container or the value of the member
+    Stop                 // no more events, ParseEvents returns
+)
+
+// Handler handles the events of ParseEvents
+type Handler func(e Event) Signal
+
+// stopEvents unwinds the parser when a Handler answers Stop
+type stopEvents struct{}
+
+// ParseEvents parses the json text as opts says and tells h about the nodes as they are
+// parsed, without building them. the errors are told as they are found, and the parser
+// recovers from them as Parser does, so the events go on; ParseEvents returns the errors
+// Parser gives, or the ones found before h answered Stop
+func ParseEvents(source string, opts Options, h Handler) (errs []jsonError) {
+    var b = &eventBuilder{handle: h}
+    var p = &parser{scanner: NewScannerWithOptions(source, opts), limits: opts, build: b, tolerant: true}
+    p.report = func(jerr jsonError) {
+        b.signal(h(Event{Typ: SyntaxError, Err: jerr, Loc: jerr.loc, End: jerr.loc}))
+    }
+    defer func() {
+        if r := recover(); r != nil {
+            if _, ok := r.(stopEvents); !ok { panic(r) }
+            errs = p.jerrs
+            if len(p.lexErrs) != 0 { errs = p.lexErrs }
+        }
+    }()
+    return p.run(symbol{nonterminal, 0})
+}
+
+// eventBuilder tells a Handler about the semantic actions
+type eventBuilder struct {
+    handle Handler
+    open   []EventType // the end events of the containers told about, innermost last
+    skip   int         // depth in the subtree skipped, 0 when none is
+    member bool        // the value of a member is skipped
+}
+
+func (b *eventBuilder) act(a action, token jsonToken) {
+    var e = Event{Val: token.Val, Loc: token.Loc, End: token.End}
+    switch a {
+    case objectAction, arrayAction:
+        if b.skip > 0 { b.skip++; return }
+        e.Typ, e.Val = StartObject, ""
+        if a == arrayAction { e.Typ = StartArray }
+        if b.signal(b.handle(e)) { b.skip = 1; return }
+        b.open = append(b.open, e.Typ+1)
+    case endAction:
+        if b.skip > 0 { b.skip--; return }
+        e.Typ, e.Val = b.open[len(b.open)-1], ""
+        b.open = b.open[:len(b.open)-1]
+        b.signal(b.handle(e))
+    case literalAction:
+        if b.skip > 0 { return }
+        e.Typ, e.LiteralType = Value, token.Typ
+        b.signal(b.handle(e))
+    case keyAction:
+        if b.skip > 0 { return }
+        e.Typ = Key
+        if b.signal(b.handle(e)) { b.skip, b.member = 1, true }
+    case memberAction:
+        if b.member && b.skip == 1 { b.skip, b.member = 0, false }
+    }
+}
+
+// signal stops the parser on Stop, and reports SkipSubtree
+func (b *eventBuilder) signal(s Signal) bool {
+    if s == Stop { panic(stopEvents{}) }
+    return s == SkipSubtree
+}
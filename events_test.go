@@ -0,0 +1,159 @@
+package json2ast
+
+import (
+    "fmt"
+    "reflect"
+    "strings"
+    "testing"
+)
+
+var eventNames = map[EventType]string{
+    StartObject: "{", EndObject: "}", StartArray: "[", EndArray: "]", Key: "key", Value: "value", SyntaxError: "error",
+}
+
+// events is the events of source, written out one per line
+func events(source string, h Handler) (string, []jsonError) {
+    var sb strings.Builder
+    errs := ParseEvents(source, Options{}, func(e Event) Signal {
+        var text = e.Val
+        if e.Typ == SyntaxError { text = e.Err.typ.String() }
+        fmt.Fprintf(&sb, "%s %v-%v\n", strings.TrimSpace(eventNames[e.Typ]+" "+text), e.Loc, e.End)
+        if h == nil { return Continue }
+        return h(e)
+    })
+    return strings.TrimSuffix(sb.String(), "\n"), errs
+}
+
+// the events build the JsonAst which Parser gives, and ParseEvents returns the same errors
+func TestEventsMatchAst(t *testing.T) {
+    for _, source := range append(astCorpus(), `[1 2, {"a":}, 3 4]`, `{"a" 1, "b": [}`, "[\"\xff\", 1]") {
+        var values []JsonAst
+        var open []openNode
+        errs := ParseEvents(source, Options{}, func(e Event) Signal {
+            switch e.Typ {
+            case StartObject:
+                open = append(open, openNode{ast: JsonAst{Typ: Object, Loc: e.Loc}, object: objectAst{members: map[string]JsonAst{}}})
+                return Continue
+            case StartArray:
+                open = append(open, openNode{ast: JsonAst{ArrayAst: make([]JsonAst, 0), Typ: Array, Loc: e.Loc}})
+                return Continue
+            case Key:
+                open[len(open)-1].key = e.Val
+                return Continue
+            case SyntaxError:
+                return Continue
+            }
+            var v = JsonAst{LiteralAst: literalAst{Typ: e.LiteralType, Val: e.Val, Loc: e.Loc, End: e.End}, Typ: Literal, Loc: e.Loc, End: e.End}
+            if e.Typ == EndObject || e.Typ == EndArray {
+                v = open[len(open)-1].ast
+                v.ObjectAst, v.ObjectKeys, v.End = open[len(open)-1].object.members, open[len(open)-1].object.keys, e.End
+                open = open[:len(open)-1]
+            }
+            if len(open) == 0 {
+                values = append(values, v)
+            } else if n := &open[len(open)-1]; n.ast.Typ == Object {
+                n.object.add(n.key, v)
+            } else {
+                n.ast.ArrayAst = append(n.ast.ArrayAst, v)
+            }
+            return Continue
+        })
+
+        ast, jerrs := Parser(source)
+        if !reflect.DeepEqual(errs, jerrs) {
+            t.Errorf("%q gives errors %v, Parser %v", source, errs, jerrs)
+            continue
+        }
+        if len(jerrs) == 0 && (len(values) != 1 || !reflect.DeepEqual(values[0], ast)) {
+            t.Errorf("%q gives %+v, Parser %+v", source, values, ast)
+        }
+    }
+}
+
+func TestEventsSkipAndStop(t *testing.T) {
+    var source = `{"a": [1, {"b": 2}], "c": {"d": 3}, "e": [4], "f": 5, "g": 6}`
+    got, errs := events(source, func(e Event) Signal {
+        switch {
+        case e.Typ == Key && e.Val == `"a"`, e.Typ == StartArray:
+            return SkipSubtree
+        case e.Typ == Value && e.Val == "5":
+            return Stop
+        }
+        return Continue
+    })
+    var want = `{ {1 1}-{1 2}
+key "a" {1 2}-{1 5}
+key "c" {1 22}-{1 25}
+{ {1 27}-{1 28}
+key "d" {1 28}-{1 31}
+value 3 {1 33}-{1 34}
+} {1 34}-{1 35}
+key "e" {1 37}-{1 40}
+[ {1 42}-{1 43}
+key "f" {1 47}-{1 50}
+value 5 {1 52}-{1 53}`
+    if got != want || len(errs) != 0 {
+        t.Errorf("events are\n%s\nwant\n%s\nerrors %v", got, want, errs)
+    }
+}
+
+// the errors are events, the parser goes on after them
+func TestEventsErrors(t *testing.T) {
+    var source = `[1 2, {"a":}, 3]`
+    got, errs := events(source, nil)
+    var want = `[ {1 1}-{1 2}
+value 1 {1 2}-{1 3}
+error CommaOrClosingBracketExpected {1 4}-{1 4}
+{ {1 7}-{1 8}
+key "a" {1 8}-{1 11}
+error ValueExpected {1 12}-{1 12}
+} {1 12}-{1 13}
+value 3 {1 15}-{1 16}
+] {1 16}-{1 17}`
+    if got != want {
+        t.Errorf("events are\n%s\nwant\n%s", got, want)
+    }
+    if _, jerrs := Parser(source); !reflect.DeepEqual(errs, jerrs) {
+        t.Errorf("errors %v, Parser %v", errs, jerrs)
+    }
+
+    // no key is told for a property which is not there, a container which is not closed
+    // ends where the parser takes its closer as missing
+    var tests = []struct {
+        source string
+        want   string
+    }{
+        {`{"a":1, 2}`, `{ {1 1}-{1 2}
+key "a" {1 2}-{1 5}
+value 1 {1 6}-{1 7}
+error PropertyExpected {1 9}-{1 9}
+value 2 {1 9}-{1 10}
+} {1 10}-{1 11}`},
+        {`{"a":1, }`, `{ {1 1}-{1 2}
+key "a" {1 2}-{1 5}
+value 1 {1 6}-{1 7}
+error TrailingComma {1 7}-{1 7}
+} {1 9}-{1 10}`},
+        {`[{"a":1`, `[ {1 1}-{1 2}
+{ {1 2}-{1 3}
+key "a" {1 3}-{1 6}
+value 1 {1 7}-{1 8}
+error CommaOrClosingBraceExpected {-1 -1}-{-1 -1}
+} {-1 -1}-{-1 -1}
+] {-1 -1}-{-1 -1}`},
+    }
+    for _, test := range tests {
+        if got, _ := events(test.source, nil); got != test.want {
+            t.Errorf("events of %s are\n%s\nwant\n%s", test.source, got, test.want)
+        }
+    }
+
+    // a handler can stop at an error
+    got, errs = events(`[1 2, 3]`, func(e Event) Signal {
+        if e.Typ == SyntaxError { return Stop }
+        return Continue
+    })
+    if strings.Count(got, "\n") != 2 || len(errs) != 1 {
+        t.Errorf("events are\n%s\nerrors %v", got, errs)
+    }
+}
@@ -0,0 +1,150 @@
+package json2ast
+
+import (
+    "reflect"
+    "sort"
+    "strings"
+    "sync"
+)
+
+// field is a struct field visible to json, possibly promoted from an embedded struct
+type field struct {
+    name      string
+    index     []int
+    typ       reflect.Type
+    tagged    bool
+    omitEmpty bool
+    quoted    bool // the ,string option: a bool, number or string written inside a json string
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+func cachedTypeFields(t reflect.Type) []field {
+    if fs, ok := fieldCache.Load(t); ok {
+        return fs.([]field)
+    }
+    fs, _ := fieldCache.LoadOrStore(t, typeFields(t))
+    return fs.([]field)
+}
+
+func parseTag(tag string) (string, []string) {
+    var parts = strings.Split(tag, ",")
+    return parts[0], parts[1:]
+}
+
+func hasOption(opts []string, opt string) bool {
+    for _, o := range opts {
+        if o == opt { return true }
+    }
+    return false
+}
+
+// typeFields returns the fields json should recognize for the struct type t,
+// following the visibility rules of encoding/json for embedded structs
+func typeFields(t reflect.Type) []field {
+    type visit struct {
+        typ   reflect.Type
+        index []int
+    }
+
+    var current []visit
+    var next = []visit{{typ: t}}
+    var count, nextCount map[reflect.Type]int
+    var visited = map[reflect.Type]bool{}
+    var fields []field
+
+    for len(next) > 0 {
+        current, next = next, nil
+        count, nextCount = nextCount, map[reflect.Type]int{}
+
+        for _, v := range current {
+            if visited[v.typ] { continue }
+            visited[v.typ] = true
+
+            for i := 0; i < v.typ.NumField(); i++ {
+                var sf = v.typ.Field(i)
+                if sf.Anonymous {
+                    var ft = sf.Type
+                    if ft.Kind() == reflect.Ptr { ft = ft.Elem() }
+                    if !sf.IsExported() && ft.Kind() != reflect.Struct { continue }
+                } else if !sf.IsExported() {
+                    continue
+                }
+
+                var tag = sf.Tag.Get("json")
+                if tag == "-" { continue }
+                name, opts := parseTag(tag)
+
+                var index = make([]int, len(v.index)+1)
+                copy(index, v.index)
+                index[len(v.index)] = i
+
+                var ft = sf.Type
+                if ft.Name() == "" && ft.Kind() == reflect.Ptr { ft = ft.Elem() }
+
+                if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+                    var f = field{
+                        name:      name,
+                        index:     index,
+                        typ:       sf.Type,
+                        tagged:    name != "",
+                        omitEmpty: hasOption(opts, "omitempty"),
+                    }
+                    if hasOption(opts, "string") {
+                        switch ft.Kind() {
+                        case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+                            reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+                            reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+                            f.quoted = true
+                        }
+                    }
+                    if f.name == "" { f.name = sf.Name }
+                    fields = append(fields, f)
+                    // the same type embedded twice at this depth: keep a duplicate so both annihilate
+                    if count[v.typ] > 1 { fields = append(fields, f) }
+                    continue
+                }
+
+                // an untagged embedded struct, visit its fields at the next depth
+                nextCount[ft]++
+                if nextCount[ft] == 1 { next = append(next, visit{ft, index}) }
+            }
+        }
+    }
+
+    sort.Slice(fields, func(i, j int) bool {
+        var x, y = fields[i], fields[j]
+        if x.name != y.name { return x.name < y.name }
+        if len(x.index) != len(y.index) { return len(x.index) < len(y.index) }
+        if x.tagged != y.tagged { return x.tagged }
+        return lessIndex(x.index, y.index)
+    })
+
+    // among fields with the same name keep the dominant one, drop the name if ambiguous
+    var out = fields[:0]
+    for i := 0; i < len(fields); {
+        var j = i + 1
+        for j < len(fields) && fields[j].name == fields[i].name { j++ }
+        if dominant, ok := dominantField(fields[i:j]); ok {
+            out = append(out, dominant)
+        }
+        i = j
+    }
+
+    sort.Slice(out, func(i, j int) bool { return lessIndex(out[i].index, out[j].index) })
+    return out
+}
+
+func dominantField(fields []field) (field, bool) {
+    if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) && fields[0].tagged == fields[1].tagged {
+        return field{}, false
+    }
+    return fields[0], true
+}
+
+func lessIndex(x, y []int) bool {
+    for k := 0; k < len(x) && k < len(y); k++ {
+        if x[k] != y[k] { return x[k] < y[k] }
+    }
+    return len(x) < len(y)
+}
@@ -0,0 +1,106 @@
+package json2ast
+
+import (
+    "encoding/json"
+    "strings"
+    "testing"
+    "time"
+    "unicode/utf8"
+)
+
+// fuzzTimeout bounds one call, so a loop in the error recovery fails instead of hanging
+const fuzzTimeout = 5 * time.Second
+
+func addFuzzSeeds(f *testing.F) {
+    for _, vt := range parserValidTests {
+        f.Add(vt)
+    }
+    for _, s := range []string {
+        "", " ", "{", "[", "]", "}", ",", ":", `"`, `"\`, `"\u`, `"\ud800"`, "\xff", "\ufeff{}",
+        `{"a" 1}`, `{"a":}`, `{,}`, `[1,]`, `[,1]`, `[1 2]`, `{"a":1,,"b":2}`, `[[[[`, `]]]]`,
+        "01", "+1", "-", "1.", "1e", "1e+", "١", "tru", "nul", "falsey", "[true false]",
+        "{\"a\":\n[1,\n2\n", "\"a\nb\"", `{"a":[{"b":null}],"c":{}}`,
+    } {
+        f.Add(s)
+    }
+}
+
+// callWithTimeout runs fn and fails the test if it does not return in time
+func callWithTimeout(t *testing.T, name string, fn func()) {
+    var done = make(chan struct{})
+    go func() {
+        defer close(done)
+        fn()
+    }()
+    select {
+    case <-done:
+    case <-time.After(fuzzTimeout):
+        t.Fatalf("%s does not return", name)
+    }
+}
+
+// checkErrorLocations checks that every error is at a character of the source,
+// or at its end ([-1, -1])
+func checkErrorLocations(t *testing.T, source string, jerrs []jsonError) {
+    var lines = strings.Split(source, "\n")
+    for _, jerr := range jerrs {
+        if jerr.loc == (location{-1, -1}) { continue }
+        var line, col = jerr.loc.lineNum, jerr.loc.position
+        if line < 1 || line > len(lines) || col < 1 || col > utf8.RuneCountInString(lines[line-1]) {
+            t.Fatalf("error %v is outside of %q", jerr, source)
+        }
+    }
+}
+
+func FuzzTokenize(f *testing.F) {
+    addFuzzSeeds(f)
+    f.Fuzz(func(t *testing.T, source string) {
+        var tokens []Token
+        var jerrs []jsonError
+        callWithTimeout(t, "Tokenize", func() { tokens, jerrs = Tokenize(source) })
+        checkErrorLocations(t, source, jerrs)
+
+        var offset = 0
+        for _, token := range tokens {
+            var span = token.Span
+            if span.Start.Offset < offset || span.End.Offset <= span.Start.Offset || span.End.Offset > len(source) {
+                t.Fatalf("token %+v is out of order or outside of %q", token, source)
+            }
+            if source[span.Start.Offset:span.End.Offset] != token.Val {
+                t.Fatalf("token %+v does not match its span in %q", token, source)
+            }
+            offset = span.End.Offset
+
+            // a token is lexed to itself on its own
+            again, errs := Tokenize(token.Val)
+            if len(errs) != 0 || len(again) != 1 || again[0].Typ != token.Typ || again[0].Val != token.Val {
+                t.Fatalf("token %+v of %q is lexed to %v, %v on its own", token, source, again, errs)
+            }
+        }
+    })
+}
+
+func FuzzParser(f *testing.F) {
+    addFuzzSeeds(f)
+    f.Fuzz(func(t *testing.T, source string) {
+        var ast JsonAst
+        var jerrs []jsonError
+        callWithTimeout(t, "Parser", func() { ast, jerrs = Parser(source) })
+        checkErrorLocations(t, source, jerrs)
+
+        if len(jerrs) != 0 {
+            if ast.Typ != Object || ast.ObjectAst != nil || ast.ArrayAst != nil || ast.LiteralAst != (literalAst{}) {
+                t.Fatalf("%q gives errors %v and a non empty ast", source, jerrs)
+            }
+        } else if !utf8.ValidString(source) {
+            t.Fatalf("%q is accepted but is not utf-8", source)
+        }
+
+        // encoding/json accepts invalid utf-8 and lone surrogates in strings
+        var replaced []jsonError
+        callWithTimeout(t, "ParserWithOptions", func() { _, replaced = ParserWithOptions(source, Options{ReplaceInvalid: true}) })
+        if accepted, valid := len(replaced) == 0, json.Valid([]byte(source)); accepted != valid {
+            t.Fatalf("%q: accepted %v, json.Valid %v, errors %v", source, accepted, valid, replaced)
+        }
+    })
+}
@@ -1,3 +1,3 @@
 module github.com/YuHover/json2ast
 
-go 1.17
+go 1.18
@@ -0,0 +1,267 @@
+package json2ast
+
+import (
+    "bytes"
+    "fmt"
+    "go/format"
+    "strconv"
+    "strings"
+    "unicode"
+)
+
+type goKind uint8
+
+const (
+    goNull goKind = iota // only null was seen
+    goAny
+    goBool
+    goInt
+    goFloat
+    goString
+    goStruct
+    goSlice
+)
+
+// goType is the go type inferred for the values at one location of the sample
+type goType struct {
+    kind     goKind
+    nullable bool
+    fields   []*goField // goStruct, in document order
+    elem     *goType    // goSlice
+    name     string     // goStruct, assigned when rendering
+}
+
+type goField struct {
+    key      string // member name
+    typ      *goType
+    optional bool // missing from some of the merged objects
+}
+
+// commonInitialisms are written in upper case in field names, as golint suggests
+var commonInitialisms = map[string]bool{
+    "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+    "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+    "JSON": true, "LHS": true, "QPS": true, "RAM": true, "RHS": true, "RPC": true,
+    "SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true,
+    "UI": true, "UID": true, "URI": true, "URL": true, "UTF8": true, "UUID": true,
+    "VM": true, "XML": true,
+}
+
+// GenerateGoTypes generates go type declarations able to decode documents shaped like
+// the sample: objects become structs with `json` tags and fields in document order,
+// nullable values become pointers and arrays of mixed values []interface{}.
+// the root type is named typeName, nested structs are named after their fields
+func GenerateGoTypes(sample JsonAst, typeName string) ([]byte, error) {
+    var root = inferGoType(sample)
+    var g = goGenerator{used: map[string]bool{}}
+
+    typeName = goIdentifier(typeName)
+    g.used[typeName] = true
+    if root.kind == goStruct { root.name = typeName }
+    g.nameStructs(root, typeName)
+
+    fmt.Fprintf(&g.buf, "type %s %s\n", typeName, g.typeExpr(root, true))
+    for _, st := range g.structs {
+        if st == root { continue }
+        fmt.Fprintf(&g.buf, "\ntype %s %s\n", st.name, g.structExpr(st))
+    }
+
+    return format.Source(g.buf.Bytes())
+}
+
+func inferGoType(ast JsonAst) *goType {
+    switch ast.Typ {
+    case Object:
+        var t = &goType{kind: goStruct}
+        for _, k := range objectKeys(ast) {
+            name, _ := unquote(k)
+            t.fields = append(t.fields, &goField{key: name, typ: inferGoType(ast.ObjectAst[k])})
+        }
+        return t
+    case Array:
+        var elem *goType
+        for _, e := range ast.ArrayAst {
+            if elem == nil {
+                elem = inferGoType(e)
+            } else {
+                elem = mergeGoTypes(elem, inferGoType(e))
+            }
+        }
+        if elem == nil { elem = &goType{kind: goAny} }
+        return &goType{kind: goSlice, elem: elem}
+    }
+
+    switch ast.LiteralAst.Typ {
+    case String:
+        return &goType{kind: goString}
+    case Boolean:
+        return &goType{kind: goBool}
+    case Number:
+        // integers past the range of int64 are float64, as encoding/json decodes them
+        if _, err := strconv.ParseInt(ast.LiteralAst.Val, 10, 64); err == nil {
+            return &goType{kind: goInt}
+        }
+        return &goType{kind: goFloat}
+    }
+    return &goType{kind: goNull}
+}
+
+// mergeGoTypes unifies the types of values found at the same location
+func mergeGoTypes(a, b *goType) *goType {
+    switch {
+    case a.kind == goNull:
+        var t = *b
+        t.nullable = true
+        return &t
+    case b.kind == goNull:
+        var t = *a
+        t.nullable = true
+        return &t
+    }
+
+    var nullable = a.nullable || b.nullable
+    if a.kind != b.kind {
+        if a.kind == goInt && b.kind == goFloat || a.kind == goFloat && b.kind == goInt {
+            return &goType{kind: goFloat, nullable: nullable}
+        }
+        return &goType{kind: goAny}
+    }
+
+    switch a.kind {
+    case goSlice:
+        return &goType{kind: goSlice, nullable: nullable, elem: mergeGoTypes(a.elem, b.elem)}
+    case goStruct:
+        var t = &goType{kind: goStruct, nullable: nullable}
+        var byKey = map[string]*goField{}
+        for _, f := range a.fields {
+            var nf = &goField{key: f.key, typ: f.typ, optional: f.optional}
+            byKey[f.key] = nf
+            t.fields = append(t.fields, nf)
+        }
+        var inB = map[string]bool{}
+        for _, f := range b.fields {
+            inB[f.key] = true
+            if nf, ok := byKey[f.key]; ok {
+                nf.typ = mergeGoTypes(nf.typ, f.typ)
+                nf.optional = nf.optional || f.optional
+                continue
+            }
+            var nf = &goField{key: f.key, typ: f.typ, optional: true}
+            byKey[f.key] = nf
+            t.fields = append(t.fields, nf)
+        }
+        for _, f := range a.fields {
+            if !inB[f.key] { byKey[f.key].optional = true }
+        }
+        return t
+    }
+
+    return &goType{kind: a.kind, nullable: nullable}
+}
+
+type goGenerator struct {
+    buf     bytes.Buffer
+    structs []*goType // in the order they are declared
+    used    map[string]bool
+}
+
+func (g *goGenerator) uniqueName(name string) string {
+    var unique = name
+    for i := 2; g.used[unique]; i++ {
+        unique = fmt.Sprintf("%s%d", name, i)
+    }
+    g.used[unique] = true
+    return unique
+}
+
+// nameStructs names the struct types found under t, depth first in document order
+func (g *goGenerator) nameStructs(t *goType, name string) {
+    switch t.kind {
+    case goStruct:
+        if t.name == "" { t.name = g.uniqueName(name) }
+        g.structs = append(g.structs, t)
+        for _, f := range t.fields {
+            g.nameStructs(f.typ, t.name+goIdentifier(f.key))
+        }
+    case goSlice:
+        g.nameStructs(t.elem, singular(name))
+    }
+}
+
+func (g *goGenerator) typeExpr(t *goType, root bool) string {
+    var expr string
+    switch t.kind {
+    case goNull, goAny:
+        return "interface{}"
+    case goBool:
+        expr = "bool"
+    case goInt:
+        expr = "int64"
+    case goFloat:
+        expr = "float64"
+    case goString:
+        expr = "string"
+    case goSlice:
+        return "[]" + g.typeExpr(t.elem, false)
+    case goStruct:
+        if root { return g.structExpr(t) }
+        expr = t.name
+    }
+    if t.nullable { return "*" + expr }
+    return expr
+}
+
+func (g *goGenerator) structExpr(t *goType) string {
+    var sb strings.Builder
+    var names = map[string]bool{}
+    sb.WriteString("struct {\n")
+    for _, f := range t.fields {
+        var name = goIdentifier(f.key)
+        for i := 2; names[name]; i++ {
+            name = fmt.Sprintf("%s%d", goIdentifier(f.key), i)
+        }
+        names[name] = true
+
+        var tag = f.key
+        if f.optional { tag += ",omitempty" }
+        fmt.Fprintf(&sb, "%s %s `json:%s`\n", name, g.typeExpr(f.typ, false), quoteTag(tag))
+    }
+    sb.WriteString("}")
+    return sb.String()
+}
+
+// quoteTag quotes the tag value the way reflect.StructTag.Get unquotes it
+func quoteTag(s string) string {
+    return "\"" + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + "\""
+}
+
+// goIdentifier turns a member name into an exported go identifier: foo_bar_id -> FooBarID
+func goIdentifier(key string) string {
+    var words = strings.FieldsFunc(key, func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+
+    var sb strings.Builder
+    for _, w := range words {
+        if u := strings.ToUpper(w); commonInitialisms[u] {
+            sb.WriteString(u)
+            continue
+        }
+        var rs = []rune(w)
+        rs[0] = unicode.ToUpper(rs[0])
+        sb.WriteString(string(rs))
+    }
+
+    var id = sb.String()
+    if id == "" { return "Field" }
+    if r := []rune(id)[0]; !unicode.IsUpper(r) { id = "X" + id }
+    return id
+}
+
+// singular names the element type of a slice: Items -> Item, Data -> DataItem
+func singular(name string) string {
+    if len(name) > 1 && strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") {
+        return name[:len(name)-1]
+    }
+    return name + "Item"
+}
@@ -0,0 +1,69 @@
+package json2ast
+
+import (
+    "testing"
+)
+
+func TestGenerateGoTypes(t *testing.T) {
+    tests := []struct {
+        json     string
+        typeName string
+        want     string
+    }{
+        {
+            `{
+                "user_id": 7,
+                "name": "x",
+                "score": 1.5,
+                "active": true,
+                "meta": null,
+                "address": {"city": "Paris", "geo": [48.8, 2.3]},
+                "items": [{"sku": "a", "qty": 1}, {"sku": "b", "qty": 2.5, "note": null}, {"sku": "c", "note": "n"}],
+                "mixed": [1, "a"],
+                "empty": [],
+                "2fa": false
+            }`,
+            "order",
+            "type Order struct {\n" +
+                "\tUserID  int64         `json:\"user_id\"`\n" +
+                "\tName    string        `json:\"name\"`\n" +
+                "\tScore   float64       `json:\"score\"`\n" +
+                "\tActive  bool          `json:\"active\"`\n" +
+                "\tMeta    interface{}   `json:\"meta\"`\n" +
+                "\tAddress OrderAddress  `json:\"address\"`\n" +
+                "\tItems   []OrderItem   `json:\"items\"`\n" +
+                "\tMixed   []interface{} `json:\"mixed\"`\n" +
+                "\tEmpty   []interface{} `json:\"empty\"`\n" +
+                "\tX2fa    bool          `json:\"2fa\"`\n" +
+                "}\n\n" +
+                "type OrderAddress struct {\n" +
+                "\tCity string    `json:\"city\"`\n" +
+                "\tGeo  []float64 `json:\"geo\"`\n" +
+                "}\n\n" +
+                "type OrderItem struct {\n" +
+                "\tSku  string  `json:\"sku\"`\n" +
+                "\tQty  float64 `json:\"qty,omitempty\"`\n" +
+                "\tNote *string `json:\"note,omitempty\"`\n" +
+                "}\n",
+        },
+        {`[{"a": 1}, null]`, "List", "type List []*ListItem\n\ntype ListItem struct {\n\tA int64 `json:\"a\"`\n}\n"},
+        {`"text"`, "Name", "type Name string\n"},
+        {`[-9223372036854775808, 9223372036854775807]`, "Ints", "type Ints []int64\n"},
+        {`[1, 9223372036854775808]`, "Big", "type Big []float64\n"},
+        {`{"id": -9223372036854775809}`, "Big", "type Big struct {\n\tID float64 `json:\"id\"`\n}\n"},
+    }
+
+    for _, tt := range tests {
+        ast, jerrs := Parser(tt.json)
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed", tt.json)
+        }
+        got, err := GenerateGoTypes(ast, tt.typeName)
+        if err != nil {
+            t.Fatalf("generate types for `%s` failed: %v", tt.json, err)
+        }
+        if string(got) != tt.want {
+            t.Fatalf("types for `%s`:\n%s\nwant:\n%s", tt.json, got, tt.want)
+        }
+    }
+}
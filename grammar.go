@@ -0,0 +1,312 @@
+package json2ast
+
+import (
+    "fmt"
+    "strings"
+)
+
+// jsonRules is the simplified grammar of the README with the start production S -> E $
+// added. @names are semantic actions, they take no input and build the ast (see act).
+// the FIRST and FOLLOW sets and the predictive table are computed from the rules, so
+// extending the grammar needs no change to the parser
+var jsonRules = []string {
+    "S  -> E $",
+    "E  -> { @object O @end",
+    "E  -> [ @array A @end",
+    "E  -> string @literal",
+    "E  -> number @literal",
+    "E  -> boolean @literal",
+    "E  -> null @literal",
+    "O  -> }",
+    "O  -> string @key : E @member MS }",
+    "MS -> ", // ɛ
+    "MS -> , string @key : E @member MS",
+    "A  -> ]",
+    "A  -> @item E @element ES ]",
+    "ES -> ", // ɛ
+    "ES -> , @item E @element ES",
+}
+
+// jsonExpected is the error reported when a symbol can't be matched
+var jsonExpected = map[string]ErrorType {
+    "$": EndOfJsonExpected,
+    "S": ValueExpected,
+    "E": ValueExpected,
+    "O": PropertyOrClosingBraceExpected,
+    "MS": CommaOrClosingBraceExpected,
+    "A": ValueExpected,
+    "ES": CommaOrClosingBracketExpected,
+    "string": PropertyExpected,
+    ":": ColonExpected,
+    "}": CommaOrClosingBraceExpected,
+    "]": CommaOrClosingBracketExpected,
+}
+
+// jsonExpectedOn replaces jsonExpected when the symbol is not matched by the given token
+var jsonExpectedOn = map[symbol]map[TokenType]ErrorType {
+    jsonGrammar.nonterminal("A"): {endOfInput: CommaOrClosingBracketExpected},
+    jsonGrammar.nonterminal("MS"): {String: CommaExpected},
+}
+
+// jsonTrailing is the error reported, instead of the one expected, for a separator which
+// the closing brace or bracket of its container follows
+var jsonTrailing = map[TokenType]ErrorType {
+    Comma: TrailingComma,
+}
+
+var terminalNames = map[string]TokenType {
+    "{": LeftBrace,
+    "}": RightBrace,
+    "[": LeftBracket,
+    "]": RightBracket,
+    ",": Comma,
+    ":": Colon,
+    "string": String,
+    "number": Number,
+    "boolean": Boolean,
+    "null": Null,
+    "$": endOfInput,
+}
+
+// endOfInput is the terminal $ after the last token, in place of Invalid which never reaches the parser
+const endOfInput = Invalid
+
+const numTerminals = int(endOfInput) + 1
+
+type action uint8
+
+const (
+    objectAction action = iota // after {
+    arrayAction                // after [
+    endAction                  // after the } or ] of the container
+    literalAction
+    keyAction                  // after the key of a member
+    memberAction               // after the value of a member
+    itemAction                 // before an element of an array
+    elementAction              // after an element of an array
+)
+
+var actionNames = map[string]action {
+    "@object": objectAction,
+    "@array": arrayAction,
+    "@end": endAction,
+    "@literal": literalAction,
+    "@key": keyAction,
+    "@member": memberAction,
+    "@item": itemAction,
+    "@element": elementAction,
+}
+
+type symbolKind uint8
+
+const (
+    terminal symbolKind = iota
+    nonterminal
+    semantic
+)
+
+// symbol is a terminal (a TokenType or endOfInput), a nonterminal (its index in grammar.names)
+// or a semantic action
+type symbol struct {
+    kind symbolKind
+    id   int
+}
+
+type production struct {
+    head int
+    body []symbol
+}
+
+type grammar struct {
+    names       []string // of the nonterminals, the first one is the start symbol
+    productions []production
+    nullable    []bool
+    first       [][numTerminals]bool
+    follow      [][numTerminals]bool
+    table       [][numTerminals]int // production to expand a nonterminal on a terminal, -1 is an error
+    expected    map[symbol]ErrorType
+    closer      []int              // the terminal all the productions of a nonterminal end with, or -1
+    closes      [numTerminals]bool // the terminals in closer
+}
+
+var jsonGrammar = newGrammar(jsonRules, jsonExpected)
+
+// newGrammar compiles the rules, it panics if they are malformed or not LL(1)
+func newGrammar(rules []string, expected map[string]ErrorType) *grammar {
+    var g = &grammar{expected: map[symbol]ErrorType{}}
+    var index = map[string]int{}
+    var heads []string
+    var bodies [][]string
+    for _, rule := range rules {
+        var parts = strings.SplitN(rule, "->", 2)
+        if len(parts) != 2 { panic("json2ast: malformed rule " + rule) }
+        var head = strings.TrimSpace(parts[0])
+        if _, ok := index[head]; !ok {
+            index[head] = len(g.names)
+            g.names = append(g.names, head)
+        }
+        heads = append(heads, head)
+        bodies = append(bodies, strings.Fields(parts[1]))
+    }
+
+    var symbolOf = func(name string) symbol {
+        if t, ok := terminalNames[name]; ok { return symbol{terminal, int(t)} }
+        if a, ok := actionNames[name]; ok { return symbol{semantic, int(a)} }
+        if nt, ok := index[name]; ok { return symbol{nonterminal, nt} }
+        panic("json2ast: unknown symbol " + name)
+    }
+    for i, head := range heads {
+        var p = production{head: index[head]}
+        for _, name := range bodies[i] {
+            p.body = append(p.body, symbolOf(name))
+        }
+        g.productions = append(g.productions, p)
+    }
+    for name, typ := range expected {
+        g.expected[symbolOf(name)] = typ
+    }
+    for _, name := range g.names {
+        if _, ok := expected[name]; !ok { panic("json2ast: no error for nonterminal " + name) }
+    }
+
+    var n = len(g.names)
+    g.nullable = make([]bool, n)
+    g.first = make([][numTerminals]bool, n)
+    g.follow = make([][numTerminals]bool, n)
+    g.follow[0][endOfInput] = true
+
+    // FIRST and nullable, to a fixed point
+    for changed := true; changed; {
+        changed = false
+        for _, p := range g.productions {
+            var first, nullable = g.firstOf(p.body)
+            for t := range first {
+                if first[t] && !g.first[p.head][t] { g.first[p.head][t], changed = true, true }
+            }
+            if nullable && !g.nullable[p.head] { g.nullable[p.head], changed = true, true }
+        }
+    }
+
+    // FOLLOW, to a fixed point
+    for changed := true; changed; {
+        changed = false
+        for _, p := range g.productions {
+            for i, sym := range p.body {
+                if sym.kind != nonterminal { continue }
+                var first, nullable = g.firstOf(p.body[i+1:])
+                if nullable { first = merge(first, g.follow[p.head]) }
+                for t := range first {
+                    if first[t] && !g.follow[sym.id][t] { g.follow[sym.id][t], changed = true, true }
+                }
+            }
+        }
+    }
+
+    // the predictive table
+    g.table = make([][numTerminals]int, n)
+    for nt := range g.table {
+        for t := range g.table[nt] { g.table[nt][t] = -1 }
+    }
+    for i, p := range g.productions {
+        var first, nullable = g.firstOf(p.body)
+        if nullable { first = merge(first, g.follow[p.head]) }
+        for t := range first {
+            if !first[t] { continue }
+            if g.table[p.head][t] != -1 {
+                panic(fmt.Sprintf("json2ast: grammar is not LL(1), %s -> %s and %s -> %s on %v",
+                    heads[i], strings.Join(bodies[i], " "),
+                    heads[g.table[p.head][t]], strings.Join(bodies[g.table[p.head][t]], " "), TokenType(t)))
+            }
+            g.table[p.head][t] = i
+        }
+    }
+
+    // the closers: a nonterminal whose productions all end with the same terminal, like O
+    // with } and S with $, derives a bracketed construct. recovery stays inside the innermost one
+    g.closer = make([]int, n)
+    for nt := range g.closer { g.closer[nt] = -2 }
+    for _, p := range g.productions {
+        var last = -1
+        if len(p.body) != 0 && p.body[len(p.body)-1].kind == terminal { last = p.body[len(p.body)-1].id }
+        if g.closer[p.head] == -2 || g.closer[p.head] == last {
+            g.closer[p.head] = last
+        } else {
+            g.closer[p.head] = -1
+        }
+    }
+    for nt := range g.closer {
+        if g.closer[nt] >= 0 { g.closes[g.closer[nt]] = true }
+    }
+
+    return g
+}
+
+// scope is the index in the stack of the deepest symbol a token may match after an error:
+// the closer of the innermost construct, or the nonterminal which derives it
+func (g *grammar) scope(stack []symbol) int {
+    for i := len(stack) - 1; i >= 0; i-- {
+        if g.closerOf(stack[i]) != -1 { return i }
+    }
+    return 0
+}
+
+// closerOf is the closer which sym is or derives, or -1
+func (g *grammar) closerOf(sym symbol) int {
+    switch {
+    case sym.kind == terminal && g.closes[sym.id]:
+        return sym.id
+    case sym.kind == nonterminal:
+        return g.closer[sym.id]
+    }
+    return -1
+}
+
+// resync is the index of the topmost symbol of stack[low:] which matches t, or -1
+func (g *grammar) resync(stack []symbol, low int, t TokenType) int {
+    for i := len(stack) - 1; i >= low; i-- {
+        if g.matches(stack[i], t) { return i }
+    }
+    return -1
+}
+
+// matches tells if the token can be matched by sym, or by what sym derives first
+func (g *grammar) matches(sym symbol, t TokenType) bool {
+    switch sym.kind {
+    case terminal:
+        return TokenType(sym.id) == t
+    case nonterminal:
+        return g.table[sym.id][t] != -1
+    }
+    return false
+}
+
+// nonterminal is the nonterminal named name
+func (g *grammar) nonterminal(name string) symbol {
+    for i, n := range g.names {
+        if n == name { return symbol{nonterminal, i} }
+    }
+    panic("json2ast: unknown nonterminal " + name)
+}
+
+// firstOf is FIRST of a sequence of symbols, and whether it derives ɛ
+func (g *grammar) firstOf(symbols []symbol) ([numTerminals]bool, bool) {
+    var first [numTerminals]bool
+    for _, sym := range symbols {
+        switch sym.kind {
+        case terminal:
+            first[sym.id] = true
+            return first, false
+        case nonterminal:
+            first = merge(first, g.first[sym.id])
+            if !g.nullable[sym.id] { return first, false }
+        }
+    }
+    return first, true
+}
+
+func merge(a, b [numTerminals]bool) [numTerminals]bool {
+    for t := range b {
+        a[t] = a[t] || b[t]
+    }
+    return a
+}
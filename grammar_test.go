@@ -0,0 +1,117 @@
+package json2ast
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+// the predictive table of the README, true and false are both boolean
+func TestGrammarTable(t *testing.T) {
+    var readme = map[string]map[TokenType]string {
+        "E": {LeftBrace: "{ O", LeftBracket: "[ A", String: "string", Number: "number", Boolean: "boolean", Null: "null"},
+        "O": {RightBrace: "}", String: "string : E MS }"},
+        "MS": {RightBrace: "", Comma: ", string : E MS"},
+        "A": {LeftBrace: "E ES ]", LeftBracket: "E ES ]", RightBracket: "]", String: "E ES ]", Number: "E ES ]", Boolean: "E ES ]", Null: "E ES ]"},
+        "ES": {RightBracket: "", Comma: ", E ES"},
+    }
+
+    var g = jsonGrammar
+    for nt, name := range g.names {
+        if name == "S" { continue }
+        var row = map[TokenType]string{}
+        for t, p := range g.table[nt] {
+            if p == -1 { continue }
+            var body []string
+            for _, sym := range g.productions[p].body {
+                if sym.kind == terminal { body = append(body, nameOf(TokenType(sym.id))) }
+                if sym.kind == nonterminal { body = append(body, g.names[sym.id]) }
+            }
+            row[TokenType(t)] = strings.Join(body, " ")
+        }
+        if !reflect.DeepEqual(row, readme[name]) {
+            t.Errorf("row %s is %v, want %v", name, row, readme[name])
+        }
+    }
+}
+
+func nameOf(typ TokenType) string {
+    for name, t := range terminalNames {
+        if t == typ { return name }
+    }
+    return ""
+}
+
+func TestGrammarFollow(t *testing.T) {
+    var want = map[string][]TokenType {
+        "S": {endOfInput},
+        "E": {RightBrace, RightBracket, Comma, endOfInput},
+        "O": {RightBrace, RightBracket, Comma, endOfInput},
+        "MS": {RightBrace},
+        "A": {RightBrace, RightBracket, Comma, endOfInput},
+        "ES": {RightBracket},
+    }
+
+    var g = jsonGrammar
+    for nt, name := range g.names {
+        var follow []TokenType
+        for t, ok := range g.follow[nt] {
+            if ok { follow = append(follow, TokenType(t)) }
+        }
+        if !reflect.DeepEqual(follow, want[name]) {
+            t.Errorf("FOLLOW(%s) is %v, want %v", name, follow, want[name])
+        }
+    }
+}
+
+func TestGrammarNotLL1(t *testing.T) {
+    defer func() {
+        if r := recover(); r == nil {
+            t.Errorf("no panic for a grammar which is not LL(1)")
+        }
+    }()
+    newGrammar([]string{"S -> E $", "E -> string", "E -> string : E"}, map[string]ErrorType{"S": ValueExpected, "E": ValueExpected})
+}
+
+// panic-mode recovery reports one error and goes on from the next sync token: one in the FIRST
+// set of the top of the stack, or in its FOLLOW set and matched inside the innermost container
+func TestGrammarRecovery(t *testing.T) {
+    var tests = []struct {
+        source string
+        want   []jsonError
+    }{
+        {`]`, []jsonError{{ValueExpected, location{1, 1}}}},
+        {`1 2`, []jsonError{{EndOfJsonExpected, location{1, 3}}}},
+        {`[1,]`, []jsonError{{TrailingComma, location{1, 3}}}},
+        {`{"a":1,}`, []jsonError{{TrailingComma, location{1, 7}}}},
+        {`[1 2 3]`, []jsonError{{CommaOrClosingBracketExpected, location{1, 4}}}},
+        {`[1,2`, []jsonError{{CommaOrClosingBracketExpected, location{-1, -1}}}},
+        {`{1:2}`, []jsonError{{PropertyOrClosingBraceExpected, location{1, 2}}}},
+        {`{"a" 1, "b" 2}`, []jsonError{{ColonExpected, location{1, 6}}, {ColonExpected, location{1, 13}}}},
+        {`[1 2, {"a":}, 3 4]`, []jsonError{
+            {CommaOrClosingBracketExpected, location{1, 4}},
+            {ValueExpected, location{1, 12}},
+            {CommaOrClosingBracketExpected, location{1, 17}},
+        }},
+        // a closing brace or bracket does not close a container outside the one with the error
+        {`{"a":[}, "b":1, "c" 2}`, []jsonError{
+            {ValueExpected, location{1, 7}},
+            {CommaOrClosingBracketExpected, location{1, 13}},
+            {CommaOrClosingBracketExpected, location{1, 21}},
+        }},
+        {`{"a":[1 }, "b" 2, "c":}`, []jsonError{
+            {CommaOrClosingBracketExpected, location{1, 9}},
+            {CommaOrClosingBracketExpected, location{1, 16}},
+            {CommaOrClosingBracketExpected, location{1, 22}},
+        }},
+        {`[`, []jsonError{{CommaOrClosingBracketExpected, location{-1, -1}}}},
+        {`{,}`, []jsonError{{PropertyOrClosingBraceExpected, location{1, 2}}, {TrailingComma, location{1, 2}}}},
+    }
+
+    for _, tt := range tests {
+        ast, jerrs := Parser(tt.source)
+        if !reflect.DeepEqual(jerrs, tt.want) || ast.Typ != Object || ast.ObjectAst != nil {
+            t.Errorf("`%s` gives errors %v, want %v", tt.source, jerrs, tt.want)
+        }
+    }
+}
@@ -0,0 +1,111 @@
+package json2ast
+
+import (
+    "html"
+    "io"
+    "strings"
+)
+
+type highlightClass uint8
+
+const (
+    gapClass highlightClass = iota // whitespace between tokens
+    plainClass // punctuation
+    keyClass
+    stringClass
+    numberClass
+    booleanClass
+    nullClass
+    errorClass
+)
+
+var ansiStyles = map[highlightClass]string {
+    keyClass: "\x1b[1;34m",
+    stringClass: "\x1b[32m",
+    numberClass: "\x1b[36m",
+    booleanClass: "\x1b[33m",
+    nullClass: "\x1b[35m",
+    errorClass: "\x1b[4;31m",
+}
+
+const ansiReset = "\x1b[0m"
+
+var htmlClasses = map[highlightClass]string {
+    plainClass: "json-punct",
+    keyClass: "json-key",
+    stringClass: "json-string",
+    numberClass: "json-number",
+    booleanClass: "json-boolean",
+    nullClass: "json-null",
+    errorClass: "json-error",
+}
+
+// HighlightANSI colours the json text with ANSI escape sequences for terminals.
+// the text is kept as it is, invalid tokens are underlined in red
+func HighlightANSI(source string) string {
+    var sb strings.Builder
+    highlight(source, func(text string, class highlightClass) {
+        if style, ok := ansiStyles[class]; ok {
+            sb.WriteString(style + text + ansiReset)
+            return
+        }
+        sb.WriteString(text)
+    })
+    return sb.String()
+}
+
+// HighlightHTML escapes the json text and wraps every token in a span with one of the
+// css classes json-key, json-string, json-number, json-boolean, json-null, json-punct
+// or json-error (invalid tokens). whitespace is kept, so the result belongs in a <pre>
+func HighlightHTML(source string) string {
+    var sb strings.Builder
+    highlight(source, func(text string, class highlightClass) {
+        if class == gapClass {
+            sb.WriteString(html.EscapeString(text))
+            return
+        }
+        sb.WriteString(`<span class="` + htmlClasses[class] + `">` + html.EscapeString(text) + `</span>`)
+    })
+    return sb.String()
+}
+
+// highlight calls emit for every token and for the text between tokens
+func highlight(source string, emit func(text string, class highlightClass)) {
+    var tokens []Token
+    var s = NewScanner(source)
+    for {
+        token, err := s.Next()
+        if err == io.EOF { break }
+        tokens = append(tokens, token)
+    }
+
+    var offset = 0
+    for i, token := range tokens {
+        if gap := source[offset:token.Span.Start.Offset]; gap != "" {
+            emit(gap, gapClass)
+        }
+        emit(token.Val, classOf(tokens, i))
+        offset = token.Span.End.Offset
+    }
+    if offset < len(source) {
+        emit(source[offset:], gapClass)
+    }
+}
+
+// classOf classifies tokens[i], strings followed by a colon are object keys
+func classOf(tokens []Token, i int) highlightClass {
+    switch tokens[i].Typ {
+    case String:
+        if i+1 < len(tokens) && tokens[i+1].Typ == Colon { return keyClass }
+        return stringClass
+    case Number:
+        return numberClass
+    case Boolean:
+        return booleanClass
+    case Null:
+        return nullClass
+    case Invalid:
+        return errorClass
+    }
+    return plainClass
+}
@@ -0,0 +1,27 @@
+package json2ast
+
+import "testing"
+
+func TestHighlightHTML(t *testing.T) {
+    var source = "{\"a<b\": [1, true, null, \"x&y\"],\n \"c\": tru}"
+    var want = `<span class="json-punct">{</span><span class="json-key">&#34;a&lt;b&#34;</span>` +
+        `<span class="json-punct">:</span> <span class="json-punct">[</span><span class="json-number">1</span>` +
+        `<span class="json-punct">,</span> <span class="json-boolean">true</span><span class="json-punct">,</span> ` +
+        `<span class="json-null">null</span><span class="json-punct">,</span> <span class="json-string">&#34;x&amp;y&#34;</span>` +
+        `<span class="json-punct">]</span><span class="json-punct">,</span>` + "\n " +
+        `<span class="json-key">&#34;c&#34;</span><span class="json-punct">:</span> <span class="json-error">tru</span>` +
+        `<span class="json-punct">}</span>`
+
+    if got := HighlightHTML(source); got != want {
+        t.Fatalf("HighlightHTML gives\n%s\nwant\n%s", got, want)
+    }
+}
+
+func TestHighlightANSI(t *testing.T) {
+    var source = " {\"a\": [\"b\", 1e2, fals]} "
+    var want = " {\x1b[1;34m\"a\"\x1b[0m: [\x1b[32m\"b\"\x1b[0m, \x1b[36m1e2\x1b[0m, \x1b[4;31mfals\x1b[0m]} "
+
+    if got := HighlightANSI(source); got != want {
+        t.Fatalf("HighlightANSI gives %q, want %q", got, want)
+    }
+}
@@ -0,0 +1,129 @@
+package json2ast
+
+import (
+    "sort"
+)
+
+const (
+    enumMaxValues = 5 // strings with at most this many distinct values are enum candidates
+    enumMinRatio  = 2 // ... when every value occurs this many times on average
+)
+
+// shape accumulates the values seen at one location of the samples
+type shape struct {
+    types map[string]bool
+
+    objects   int
+    props     map[string]*shape
+    propCount map[string]int
+
+    items *shape
+
+    strings     int
+    stringSet   map[string]bool
+    manyStrings bool
+}
+
+func newShape() *shape {
+    return &shape{types: map[string]bool{}}
+}
+
+// InferSchema infers a JSON Schema (draft 2020-12) that all the samples satisfy:
+// types of every location with union types where they differ, required members
+// present in every object, item schemas of arrays and enums for strings of low cardinality
+func InferSchema(samples ...JsonAst) JsonAst {
+    var root = newShape()
+    for _, sample := range samples {
+        root.add(sample)
+    }
+
+    var schema = root.schema()
+    schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+    ast, _ := FromInterface(schema) // only strings, bools, maps and slices
+    return ast
+}
+
+func (sp *shape) add(ast JsonAst) {
+    var typ = jsonType(ast)
+    if typ == "number" && isInteger(ast) { typ = "integer" }
+    sp.types[typ] = true
+
+    switch typ {
+    case "object":
+        if sp.props == nil {
+            sp.props = map[string]*shape{}
+            sp.propCount = map[string]int{}
+        }
+        sp.objects++
+        for k, v := range ast.ObjectAst {
+            name, _ := unquote(k)
+            if sp.props[name] == nil { sp.props[name] = newShape() }
+            sp.props[name].add(v)
+            sp.propCount[name]++
+        }
+    case "array":
+        if sp.items == nil { sp.items = newShape() }
+        for _, elem := range ast.ArrayAst {
+            sp.items.add(elem)
+        }
+    case "string":
+        sp.strings++
+        if sp.manyStrings { break }
+        if sp.stringSet == nil { sp.stringSet = map[string]bool{} }
+        s, _ := unquote(ast.LiteralAst.Val)
+        sp.stringSet[s] = true
+        if len(sp.stringSet) > enumMaxValues {
+            sp.manyStrings = true
+            sp.stringSet = nil
+        }
+    }
+}
+
+func (sp *shape) schema() map[string]interface{} {
+    var schema = map[string]interface{}{}
+
+    if sp.types["integer"] && sp.types["number"] { delete(sp.types, "integer") }
+    var types []interface{}
+    for _, t := range []string{"null", "boolean", "integer", "number", "string", "array", "object"} {
+        if sp.types[t] { types = append(types, t) }
+    }
+    switch len(types) {
+    case 0: // an array that was always empty, any item is allowed
+        return schema
+    case 1:
+        schema["type"] = types[0]
+    default:
+        schema["type"] = types
+    }
+
+    if sp.props != nil {
+        var props = map[string]interface{}{}
+        var required []string
+        for name, p := range sp.props {
+            props[name] = p.schema()
+            if sp.propCount[name] == sp.objects { required = append(required, name) }
+        }
+        schema["properties"] = props
+        if len(required) != 0 {
+            sort.Strings(required)
+            var list = make([]interface{}, len(required))
+            for i, name := range required { list[i] = name }
+            schema["required"] = list
+        }
+    }
+
+    if sp.items != nil && len(sp.items.types) != 0 {
+        schema["items"] = sp.items.schema()
+    }
+
+    if len(types) == 1 && sp.stringSet != nil && sp.strings >= enumMinRatio*len(sp.stringSet) {
+        var values []string
+        for s := range sp.stringSet { values = append(values, s) }
+        sort.Strings(values)
+        var enum = make([]interface{}, len(values))
+        for i, s := range values { enum[i] = s }
+        schema["enum"] = enum
+    }
+
+    return schema
+}
@@ -0,0 +1,47 @@
+package json2ast
+
+import (
+    "testing"
+)
+
+func TestInferSchema(t *testing.T) {
+    samples := []string {
+        `{"id": 1, "status": "open", "tags": ["a", "b"], "owner": {"name": "x"}, "score": 1}`,
+        `{"id": 2, "status": "closed", "tags": [], "owner": null, "score": 2.5}`,
+        `{"id": 3, "status": "open", "tags": ["c", 1], "owner": {"name": "y", "mail": "y@z"}, "score": 3}`,
+        `{"id": 4, "status": "closed", "extra": [[]], "owner": {"name": "z"}}`,
+    }
+    want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","properties":{` +
+        `"extra":{"items":{"type":"array"},"type":"array"},` +
+        `"id":{"type":"integer"},` +
+        `"owner":{"properties":{"mail":{"type":"string"},"name":{"type":"string"}},"required":["name"],"type":["null","object"]},` +
+        `"score":{"type":"number"},` +
+        `"status":{"enum":["closed","open"],"type":"string"},` +
+        `"tags":{"items":{"type":["integer","string"]},"type":"array"}},` +
+        `"required":["id","owner","status"],"type":"object"}`
+
+    var asts []JsonAst
+    for _, s := range samples {
+        ast, jerrs := Parser(s)
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed", s)
+        }
+        asts = append(asts, ast)
+    }
+
+    inferred := InferSchema(asts...)
+    got, err := Canonicalize(inferred)
+    if err != nil || string(got) != want {
+        t.Fatalf("inferred schema is `%s` (%v), want `%s`", got, err, want)
+    }
+
+    schema, err := CompileSchemaAst(inferred)
+    if err != nil {
+        t.Fatalf("compile inferred schema failed: %v", err)
+    }
+    for _, ast := range asts {
+        if vs := schema.Validate(ast); len(vs) != 0 {
+            t.Fatalf("sample violates the inferred schema: %v", vs)
+        }
+    }
+}
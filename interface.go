@@ -0,0 +1,137 @@
+package json2ast
+
+import (
+    "encoding/json"
+    "fmt"
+    "math/big"
+    "strconv"
+)
+
+// NumberMode selects the go type numbers are converted to by ToInterface
+type NumberMode uint8
+
+const (
+    Float64Number NumberMode = iota // float64
+    JSONNumber                      // json.Number, the number text as is
+    BigFloatNumber                  // *big.Float, precise enough for the number text
+)
+
+// ToInterface converts the ast into the generic shape used by encoding/json:
+// map[string]interface{}, []interface{}, string, bool, nil, and numbers as selected by mode
+func ToInterface(ast JsonAst, mode NumberMode) (interface{}, error) {
+    return toInterface(ast, mode, "")
+}
+
+func toInterface(ast JsonAst, mode NumberMode, path string) (interface{}, error) {
+    switch ast.Typ {
+    case Object:
+        var m = make(map[string]interface{}, len(ast.ObjectAst))
+        for k, member := range ast.ObjectAst {
+            name, err := unquote(k)
+            if err != nil {
+                return nil, newDecodeError(member, path, "%v", err)
+            }
+            val, err := toInterface(member, mode, path+"/"+escapePointerToken(name))
+            if err != nil {
+                return nil, err
+            }
+            m[name] = val
+        }
+        return m, nil
+    case Array:
+        var s = make([]interface{}, len(ast.ArrayAst))
+        for i, elem := range ast.ArrayAst {
+            val, err := toInterface(elem, mode, path+"/"+strconv.Itoa(i))
+            if err != nil {
+                return nil, err
+            }
+            s[i] = val
+        }
+        return s, nil
+    }
+
+    var raw = ast.LiteralAst.Val
+    switch ast.LiteralAst.Typ {
+    case String:
+        s, err := unquote(raw)
+        if err != nil {
+            return nil, newDecodeError(ast, path, "%v", err)
+        }
+        return s, nil
+    case Number:
+        switch mode {
+        case JSONNumber:
+            return json.Number(raw), nil
+        case BigFloatNumber:
+            // about 3.3 bits per decimal digit, so the text is represented exactly when possible
+            f, _, err := big.ParseFloat(raw, 10, uint(len(raw))*4+64, big.ToNearestEven)
+            if err != nil {
+                return nil, newDecodeError(ast, path, "%v", err)
+            }
+            return f, nil
+        default:
+            f, err := strconv.ParseFloat(raw, 64)
+            if err != nil {
+                return nil, newDecodeError(ast, path, "number %s overflows float64", raw)
+            }
+            return f, nil
+        }
+    case Boolean:
+        return raw == "true", nil
+    case Null:
+        return nil, nil
+    }
+
+    return nil, newDecodeError(ast, path, "unknown literal %s", raw)
+}
+
+// FromInterface builds a JsonAst from the generic shape returned by ToInterface,
+// other values are converted with FromValue
+func FromInterface(v interface{}) (JsonAst, error) {
+    switch v := v.(type) {
+    case nil:
+        return nullNode, nil
+    case bool:
+        return literalNode(Boolean, strconv.FormatBool(v)), nil
+    case string:
+        return literalNode(String, quote(v)), nil
+    case float64:
+        s, err := formatES6Number(v)
+        if err != nil {
+            return JsonAst{}, err
+        }
+        return literalNode(Number, s), nil
+    case json.Number:
+        return FromValue(v)
+    case *big.Float:
+        if v == nil {
+            return nullNode, nil
+        }
+        if v.IsInf() {
+            return JsonAst{}, fmt.Errorf("number %v can not be represented in json", v)
+        }
+        return literalNode(Number, v.Text('g', -1)), nil
+    case map[string]interface{}:
+        var ast = JsonAst{ObjectAst: make(map[string]JsonAst, len(v)), Typ: Object}
+        for name, val := range v {
+            member, err := FromInterface(val)
+            if err != nil {
+                return JsonAst{}, err
+            }
+            ast.ObjectAst[quote(name)] = member
+        }
+        return ast, nil
+    case []interface{}:
+        var ast = JsonAst{ArrayAst: make([]JsonAst, 0, len(v)), Typ: Array}
+        for _, val := range v {
+            elem, err := FromInterface(val)
+            if err != nil {
+                return JsonAst{}, err
+            }
+            ast.ArrayAst = append(ast.ArrayAst, elem)
+        }
+        return ast, nil
+    }
+
+    return FromValue(v)
+}
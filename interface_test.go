@@ -0,0 +1,64 @@
+package json2ast
+
+import (
+    "encoding/json"
+    "math/big"
+    "reflect"
+    "testing"
+)
+
+func TestInterfaceRoundTrip(t *testing.T) {
+    for _, vt := range parserValidTests {
+        ast, jerrs := Parser(vt)
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed", vt)
+        }
+        want, err := Canonicalize(ast)
+        if err != nil {
+            t.Fatalf("canonicalize `%s` failed: %v", vt, err)
+        }
+
+        for _, mode := range []NumberMode{Float64Number, JSONNumber, BigFloatNumber} {
+            v, err := ToInterface(ast, mode)
+            if err != nil {
+                t.Fatalf("convert `%s` with mode %d failed: %v", vt, mode, err)
+            }
+
+            if mode == Float64Number {
+                var std interface{}
+                _ = json.Unmarshal([]byte(vt), &std)
+                if !reflect.DeepEqual(v, std) {
+                    t.Fatalf("convert `%s` gives %v, encoding/json gives %v", vt, v, std)
+                }
+            }
+
+            back, err := FromInterface(v)
+            if err != nil {
+                t.Fatalf("build AST from %v failed: %v", v, err)
+            }
+            got, err := Canonicalize(back)
+            if err != nil || string(got) != string(want) {
+                t.Fatalf("round trip of `%s` with mode %d gives `%s` (%v), want `%s`", vt, mode, got, err, want)
+            }
+        }
+    }
+}
+
+func TestToInterfaceNumbers(t *testing.T) {
+    ast, _ := Parser(`[12345678901234567890123, 0.1, -1e-2]`)
+
+    v, _ := ToInterface(ast, JSONNumber)
+    if n := v.([]interface{})[0].(json.Number); n != "12345678901234567890123" {
+        t.Fatalf("json.Number is %s", n)
+    }
+
+    v, _ = ToInterface(ast, BigFloatNumber)
+    want, _ := new(big.Int).SetString("12345678901234567890123", 10)
+    if n, acc := v.([]interface{})[0].(*big.Float).Int(nil); n.Cmp(want) != 0 || acc != big.Exact {
+        t.Fatalf("big.Float is %v (%v)", n, acc)
+    }
+
+    if _, err := ToInterface(literalNode(Number, "1e999"), Float64Number); err == nil {
+        t.Fatal("convert 1e999 to float64 should fail")
+    }
+}
@@ -0,0 +1,122 @@
+package json2ast
+
+import (
+    "bytes"
+    "fmt"
+    "math"
+    "sort"
+    "strconv"
+    "unicode/utf16"
+)
+
+// Canonicalize serializes the ast in the JSON Canonicalization Scheme (RFC 8785):
+// object members sorted by the UTF-16 code units of their names, numbers in
+// ECMAScript format, strings with minimal escaping and no whitespace
+func Canonicalize(ast JsonAst) ([]byte, error) {
+    var buf bytes.Buffer
+    if err := writeCanonical(&buf, ast); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+func writeCanonical(buf *bytes.Buffer, ast JsonAst) error {
+    switch ast.Typ {
+    case Object:
+        var names = make([]string, 0, len(ast.ObjectAst))
+        var values = make(map[string]JsonAst, len(ast.ObjectAst))
+        for k, v := range ast.ObjectAst {
+            name, err := unquote(k)
+            if err != nil {
+                return err
+            }
+            if _, ok := values[name]; ok {
+                return fmt.Errorf("duplicate property name %s", quote(name))
+            }
+            names = append(names, name)
+            values[name] = v
+        }
+        sort.Slice(names, func(i, j int) bool { return lessUTF16(names[i], names[j]) })
+
+        buf.WriteByte('{')
+        for i, name := range names {
+            if i != 0 { buf.WriteByte(',') }
+            buf.WriteString(quote(name))
+            buf.WriteByte(':')
+            if err := writeCanonical(buf, values[name]); err != nil {
+                return err
+            }
+        }
+        buf.WriteByte('}')
+    case Array:
+        buf.WriteByte('[')
+        for i, v := range ast.ArrayAst {
+            if i != 0 { buf.WriteByte(',') }
+            if err := writeCanonical(buf, v); err != nil {
+                return err
+            }
+        }
+        buf.WriteByte(']')
+    case Literal:
+        switch ast.LiteralAst.Typ {
+        case String:
+            s, err := unquote(ast.LiteralAst.Val)
+            if err != nil {
+                return err
+            }
+            buf.WriteString(quote(s))
+        case Number:
+            f, err := strconv.ParseFloat(ast.LiteralAst.Val, 64)
+            if err != nil {
+                return jsonError{NumberOutOfRange, ast.LiteralAst.Loc}
+            }
+            s, err := formatES6Number(f)
+            if err != nil {
+                return jsonError{NumberOutOfRange, ast.LiteralAst.Loc}
+            }
+            buf.WriteString(s)
+        default:
+            buf.WriteString(ast.LiteralAst.Val)
+        }
+    }
+
+    return nil
+}
+
+// formatES6Number formats f the way ECMAScript's Number.prototype.toString does
+func formatES6Number(f float64) (string, error) {
+    return formatFloat(f, 64)
+}
+
+// formatFloat is formatES6Number for float values of the given bit size
+func formatFloat(f float64, bits int) (string, error) {
+    if math.IsNaN(f) || math.IsInf(f, 0) {
+        return "", fmt.Errorf("number %v can not be represented in json", f)
+    }
+    if f == 0 { // also -0
+        return "0", nil
+    }
+
+    var format byte = 'f'
+    if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+        format = 'e'
+    }
+    var s = strconv.FormatFloat(f, format, -1, bits)
+    if format == 'e' {
+        // go pads the exponent to two digits: 1e-07 -> 1e-7
+        if n := len(s); s[n-4] == 'e' && s[n-2] == '0' {
+            s = s[:n-2] + s[n-1:]
+        }
+    }
+    return s, nil
+}
+
+func lessUTF16(a, b string) bool {
+    var ua, ub = utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+    for i := 0; i < len(ua) && i < len(ub); i++ {
+        if ua[i] != ub[i] {
+            return ua[i] < ub[i]
+        }
+    }
+    return len(ua) < len(ub)
+}
@@ -0,0 +1,127 @@
+package json2ast
+
+import (
+    "math"
+    "strconv"
+    "testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+    tests := []struct {
+        in   string
+        want string
+    }{
+        // RFC 8785, section 3.2.2
+        {
+            `{
+                "numbers": [333333333.33333329, 1E30, 4.50,
+                            2e-3, 0.000000000000000000000000001],
+                "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+                "literals": [null, true, false]
+            }`,
+            `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+        },
+        // RFC 8785, section 3.2.3
+        {
+            `{
+                "\u20ac": "Euro Sign",
+                "\r": "Carriage Return",
+                "\ufb33": "Hebrew Letter Dalet With Dagesh",
+                "1": "One",
+                "\ud83d\ude00": "Emoji: Grinning Face",
+                "\u0080": "Control",
+                "\u00f6": "Latin Small Letter O With Diaeresis"
+            }`,
+            "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\"," +
+                "\"\u20ac\":\"Euro Sign\",\"\U0001F600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+        },
+        {`[-0, 1e-400, 10, {"b": [], "a": {}}]`, `[0,0,10,{"a":{},"b":[]}]`},
+        {`"\u007f\u2028"`, "\"\u007f\u2028\""},
+    }
+
+    for _, tt := range tests {
+        ast, jerrs := Parser(tt.in)
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed: %v", tt.in, jerrs)
+        }
+        got, err := Canonicalize(ast)
+        if err != nil {
+            t.Fatalf("canonicalize `%s` failed: %v", tt.in, err)
+        }
+        if string(got) != tt.want {
+            t.Fatalf("canonical form of `%s` is `%s`, want `%s`", tt.in, got, tt.want)
+        }
+    }
+
+    invalidTests := []string {
+        `1e400`,
+        `[-1e309]`,
+        `{"a": 1, "\u0061": 2}`,
+    }
+    for _, ivt := range invalidTests {
+        ast, jerrs := Parser(ivt)
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed: %v", ivt, jerrs)
+        }
+        if _, err := Canonicalize(ast); err == nil {
+            t.Fatalf("canonicalize `%s` should fail", ivt)
+        }
+    }
+}
+
+func TestFormatES6Number(t *testing.T) {
+    // RFC 8785, appendix B
+    tests := []struct {
+        bits uint64
+        want string
+    }{
+        {0x0000000000000000, "0"},
+        {0x8000000000000000, "0"},
+        {0x0000000000000001, "5e-324"},
+        {0x8000000000000001, "-5e-324"},
+        {0x7fefffffffffffff, "1.7976931348623157e+308"},
+        {0xffefffffffffffff, "-1.7976931348623157e+308"},
+        {0x4340000000000000, "9007199254740992"},
+        {0xc340000000000000, "-9007199254740992"},
+        {0x4430000000000000, "295147905179352830000"},
+        {0x44b52d02c7e14af5, "9.999999999999997e+22"},
+        {0x44b52d02c7e14af6, "1e+23"},
+        {0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+        {0x444b1ae4d6e2ef4e, "999999999999999700000"},
+        {0x444b1ae4d6e2ef4f, "999999999999999900000"},
+        {0x444b1ae4d6e2ef50, "1e+21"},
+        {0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+        {0x3eb0c6f7a0b5ed8d, "0.000001"},
+        {0x41b3de4355555553, "333333333.3333332"},
+        {0x41b3de4355555554, "333333333.33333325"},
+        {0x41b3de4355555555, "333333333.3333333"},
+        {0x41b3de4355555556, "333333333.3333334"},
+        {0x41b3de4355555557, "333333333.33333343"},
+        {0xbecbf647612f3696, "-0.0000033333333333333333"},
+        {0x43143ff3c1cb0959, "1424953923781206.2"},
+    }
+
+    for _, tt := range tests {
+        var f = math.Float64frombits(tt.bits)
+        got, err := formatES6Number(f)
+        if err != nil || got != tt.want {
+            t.Fatalf("format %016x: got `%s` (%v), want `%s`", tt.bits, got, err, tt.want)
+        }
+
+        // the same value written as json number text
+        ast, jerrs := Parser(strconv.FormatFloat(f, 'g', -1, 64))
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for %016x failed: %v", tt.bits, jerrs)
+        }
+        canon, err := Canonicalize(ast)
+        if err != nil || string(canon) != tt.want {
+            t.Fatalf("canonicalize %016x: got `%s` (%v), want `%s`", tt.bits, canon, err, tt.want)
+        }
+    }
+
+    for _, bits := range []uint64{0x7fffffffffffffff, 0x7ff0000000000000} {
+        if _, err := formatES6Number(math.Float64frombits(bits)); err == nil {
+            t.Fatalf("format %016x should fail", bits)
+        }
+    }
+}
@@ -0,0 +1,225 @@
+package json2ast
+
+import (
+    "errors"
+)
+
+// LazyAst is a json text whose structure is validated once, its objects and arrays are
+// read from the source only when they are visited, skipping over the subtrees of their
+// members and elements by matching brackets. the nodes read are kept, so a LazyAst
+// must not be used by several goroutines at once
+type LazyAst struct {
+    source string
+    opts   Options
+    root   *LazyNode
+}
+
+// LazyNode is a node of a LazyAst
+type LazyNode struct {
+    doc      *LazyAst
+    typ      AstType
+    key      string   // of the member whose value it is, as written in the source
+    token    Token    // the literal, or the opening brace or bracket
+    end      Position // just past the node
+    children []*LazyNode
+    read     bool // children are read
+}
+
+// nopBuilder builds nothing, parsing with it only checks the json text
+type nopBuilder struct{}
+
+func (nopBuilder) act(a action, token jsonToken) {}
+
+func ParseLazy(source string) (*LazyAst, []jsonError) {
+    return ParseLazyWithOptions(source, Options{})
+}
+
+// ParseLazyWithOptions checks the json text as opts says, giving the errors Parser gives
+func ParseLazyWithOptions(source string, opts Options) (*LazyAst, []jsonError) {
+    if errs := parseWith(source, opts, nopBuilder{}); len(errs) != 0 {
+        return nil, errs
+    }
+    return &LazyAst{source: source, opts: opts}, nil
+}
+
+// scannerAt returns a Scanner reading the checked source from pos on, without limits
+func (doc *LazyAst) scannerAt(pos Position) *Scanner {
+    var opts = Options{ReplaceInvalid: doc.opts.ReplaceInvalid, UTF16Columns: doc.opts.UTF16Columns}
+    return newScannerAt(doc.source, opts, pos)
+}
+
+// next is the next token of the checked source
+func next(s *Scanner) Token {
+    token, _ := s.Next()
+    return token
+}
+
+// readNode reads the node starting with token, the subtree of a container is skipped
+func (doc *LazyAst) readNode(s *Scanner, token Token, key string) *LazyNode {
+    var n = &LazyNode{doc: doc, key: key, token: token, end: token.Span.End}
+    switch token.Typ {
+    case LeftBrace:
+        n.typ = Object
+        n.end = s.skipContainer()
+    case LeftBracket:
+        n.typ = Array
+        n.end = s.skipContainer()
+    default:
+        n.typ = Literal
+    }
+    return n
+}
+
+func (doc *LazyAst) Root() *LazyNode {
+    if doc.root == nil {
+        var s = doc.scannerAt(Position{0, 1, 1})
+        doc.root = doc.readNode(s, next(s), "")
+    }
+    return doc.root
+}
+
+// Lookup finds the node referenced by the json pointer (RFC 6901), reading only the
+// objects and arrays on the way to it
+func (doc *LazyAst) Lookup(pointer string) (*LazyNode, error) {
+    tokens, err := parsePointer(pointer)
+    if err != nil {
+        return nil, err
+    }
+
+    var n = doc.Root()
+    for _, token := range tokens {
+        var found = false
+        switch n.typ {
+        case Object:
+            n, found = n.memberNamed(token)
+        case Array:
+            if i, ok := arrayIndex(token); ok {
+                n, found = n.Index(i)
+            }
+        }
+        if !found {
+            return nil, errors.New("no node at json pointer " + pointer)
+        }
+    }
+    return n, nil
+}
+
+// readChildren reads the members of an object or the elements of an array
+func (n *LazyNode) readChildren() {
+    if n.read || n.typ == Literal {
+        return
+    }
+    n.read = true
+
+    var s = n.doc.scannerAt(n.token.Span.End)
+    for token := next(s); token.Typ != RightBrace && token.Typ != RightBracket; token = next(s) {
+        if token.Typ == Comma { continue }
+        var key = ""
+        if n.typ == Object {
+            key = token.Val
+            next(s) // the colon
+            token = next(s)
+        }
+        n.children = append(n.children, n.doc.readNode(s, token, key))
+    }
+}
+
+func (n *LazyNode) Type() AstType {
+    return n.typ
+}
+
+// LiteralType is the token type of a literal: String, Number, Boolean or Null
+func (n *LazyNode) LiteralType() TokenType {
+    return n.token.Typ
+}
+
+// Text is the text of a literal as written in the source, strings with their quotes
+func (n *LazyNode) Text() string {
+    if n.typ != Literal {
+        return ""
+    }
+    return n.token.Val
+}
+
+// Key is the key of the member whose value is n, as written in the source with its quotes,
+// or "" when n is not the value of a member
+func (n *LazyNode) Key() string {
+    return n.key
+}
+
+func (n *LazyNode) Loc() location {
+    return location{n.token.Span.Start.Line, n.token.Span.Start.Column}
+}
+
+// End is the location just past the last character of the node
+func (n *LazyNode) End() location {
+    return location{n.end.Line, n.end.Column}
+}
+
+// Len is the number of members of an object (duplicates included) or elements of an array
+func (n *LazyNode) Len() int {
+    n.readChildren()
+    return len(n.children)
+}
+
+// Children are the members of an object or the elements of an array, in document order
+func (n *LazyNode) Children() []*LazyNode {
+    n.readChildren()
+    return n.children
+}
+
+// Index is the i-th element of an array
+func (n *LazyNode) Index(i int) (*LazyNode, bool) {
+    if n.typ != Array || i < 0 || i >= n.Len() {
+        return nil, false
+    }
+    return n.children[i], true
+}
+
+// Member is the value of the member of an object with the key as written in the source,
+// with its quotes like the keys of JsonAst.ObjectAst. of duplicate keys the last one is taken
+func (n *LazyNode) Member(key string) (*LazyNode, bool) {
+    if n.typ != Object {
+        return nil, false
+    }
+    var found *LazyNode
+    for _, c := range n.Children() {
+        if c.key == key { found = c }
+    }
+    return found, found != nil
+}
+
+// memberNamed is the value of the member with the (unescaped) name, see member
+func (n *LazyNode) memberNamed(name string) (*LazyNode, bool) {
+    if v, ok := n.Member(quote(name)); ok {
+        return v, true
+    }
+    // the raw name may be written with other escapes
+    for _, c := range n.Children() {
+        if s, err := unquote(c.key); err == nil && s == name {
+            return c, true
+        }
+    }
+    return nil, false
+}
+
+// Ast reads the whole subtree of n into a JsonAst
+func (n *LazyNode) Ast() JsonAst {
+    var ast = JsonAst{Typ: n.typ, Loc: n.Loc(), End: n.End()}
+    switch n.typ {
+    case Object:
+        var obj = objectAst{members: map[string]JsonAst{}}
+        for _, c := range n.Children() {
+            obj.add(c.key, c.Ast())
+        }
+        ast.ObjectAst, ast.ObjectKeys = obj.members, obj.keys
+    case Array:
+        ast.ArrayAst = make([]JsonAst, 0, n.Len())
+        for _, c := range n.Children() {
+            ast.ArrayAst = append(ast.ArrayAst, c.Ast())
+        }
+    default:
+        ast.LiteralAst = literalAst(n.token.jsonToken())
+    }
+    return ast
+}
@@ -0,0 +1,95 @@
+package json2ast
+
+import (
+    "reflect"
+    "testing"
+)
+
+// a LazyAst reads the JsonAst which Parser gives, and fails with the same errors
+func TestLazyMatchesAst(t *testing.T) {
+    var options = []Options{{}, {UTF16Columns: true, ReplaceInvalid: true}}
+    for _, source := range append(astCorpus(), "\"\xff\" ", "[\"😀\", {\"é\\\"\\\\\": [\n  1]}, \"\\\\\"] ") {
+        for _, opts := range options {
+            ast, jerrs := ParserWithOptions(source, opts)
+            doc, errs := ParseLazyWithOptions(source, opts)
+            if !reflect.DeepEqual(errs, jerrs) {
+                t.Errorf("%q gives errors %v, Parser %v", source, errs, jerrs)
+                continue
+            }
+            if len(jerrs) != 0 {
+                if doc != nil { t.Errorf("%q gives a document and errors %v", source, errs) }
+                continue
+            }
+            if got := doc.Root().Ast(); !reflect.DeepEqual(got, ast) {
+                t.Errorf("%q with %+v gives %+v, Parser %+v", source, opts, got, ast)
+            }
+        }
+    }
+}
+
+func TestLazyLookup(t *testing.T) {
+    var source = `{"data": [{"a": "]}"}, [[1]]], "meta": {"name": "x", "a/b": 1, "version": "2.1"}}`
+    doc, jerrs := ParseLazy(source)
+    if len(jerrs) != 0 {
+        t.Fatalf("errors %v", jerrs)
+    }
+
+    n, err := doc.Lookup("/meta/version")
+    if err != nil || n.Text() != `"2.1"` || n.Key() != `"version"` || n.Loc() != (location{1, 75}) || n.End() != (location{1, 80}) {
+        t.Fatalf("/meta/version is %q at %v, %v", n.Text(), n.Loc(), err)
+    }
+    // the subtree of /data is skipped, not read
+    var data, _ = doc.Root().Member(`"data"`)
+    if data.read || data.End() != (location{1, 30}) {
+        t.Errorf("/data is read: %v, ends at %v", data.read, data.End())
+    }
+
+    var tests = map[string]string{
+        "/data/0/a": `"]}"`,
+        "/data/1/0/0": "1",
+        "/meta/a~1b": "1",
+    }
+    for pointer, want := range tests {
+        if n, err := doc.Lookup(pointer); err != nil || n.Text() != want {
+            t.Errorf("%s is %v, want %s", pointer, err, want)
+        }
+    }
+    for _, pointer := range []string{"/meta/nope", "/data/2", "/data/x", "meta"} {
+        if _, err := doc.Lookup(pointer); err == nil {
+            t.Errorf("%s is found", pointer)
+        }
+    }
+    if n, _ := doc.Lookup(""); n != doc.Root() || n.Len() != 2 {
+        t.Errorf("the empty pointer is not the root")
+    }
+}
+
+func BenchmarkLazyLookup(b *testing.B) {
+    var source = `{"data": ` + benchmarkDocument() + `, "meta": {"version": 2}}`
+
+    b.Run("Lazy", func(b *testing.B) {
+        b.SetBytes(int64(len(source)))
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            doc, _ := ParseLazy(source)
+            doc.Lookup("/meta/version")
+        }
+    })
+    b.Run("Skip", func(b *testing.B) { // without checking the text
+        var doc = &LazyAst{source: source}
+        b.SetBytes(int64(len(source)))
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            doc.root = nil
+            doc.Lookup("/meta/version")
+        }
+    })
+    b.Run("JsonAst", func(b *testing.B) {
+        b.SetBytes(int64(len(source)))
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            ast, _ := Parser(source)
+            Lookup(ast, "/meta/version")
+        }
+    })
+}
@@ -2,13 +2,17 @@ package json2ast
 
 import (
     "errors"
+    "io"
+    "strings"
     "unicode"
+    "unicode/utf8"
 )
 
-type tokenType uint8
+// TokenType is the type of a json token
+type TokenType uint8
 
 const (
-    LeftBrace		tokenType = iota
+    LeftBrace		TokenType = iota
     RightBrace
     LeftBracket
     RightBracket
@@ -18,26 +22,77 @@ const (
     Number
     Boolean
     Null
+
+    Invalid // text which is not a json token, Scanner returns an error with it
 )
 
+var tokenNames = map[TokenType]string {
+    LeftBrace: "LeftBrace",
+    RightBrace: "RightBrace",
+    LeftBracket: "LeftBracket",
+    RightBracket: "RightBracket",
+    Comma: "Comma",
+    Colon: "Colon",
+    String: "String",
+    Number: "Number",
+    Boolean: "Boolean",
+    Null: "Null",
+    Invalid: "Invalid",
+}
+
+func (typ TokenType) String() string {
+    return tokenNames[typ]
+}
+
 type jsonToken struct {
-    Typ tokenType
+    Typ TokenType
     Val string
     Loc location
+    End location // just past the token
+}
+
+// Position is a place in the source: byte offset, 1-based line and 1-based column
+// counted in runes, or in UTF-16 code units under Options.UTF16Columns
+type Position struct {
+    Offset int
+    Line   int
+    Column int
+}
+
+// Span covers the source from Start up to, but not including, End
+type Span struct {
+    Start Position
+    End   Position
+}
+
+// Token is a token of the json text, Val is its source text
+// (with the replacements made under Options.ReplaceInvalid)
+type Token struct {
+    Typ  TokenType
+    Val  string
+    Span Span
 }
 
 type dfsState uint8 // status of DFA
 
 // space in json
-var whiteSpace = map[rune]bool{ ' ': true, '\t': true, '\r': true, '\n': true }
+var whiteSpace = [utf8.RuneSelf]bool{ ' ': true, '\t': true, '\r': true, '\n': true }
 // delimiters
-var delimiters = map[rune]bool {
+var delimiters = [utf8.RuneSelf]bool {
     ' ': true, '\t': true, '\r': true, '\n': true,
     '{':true, '}':true, '[': true, ']': true,
     '"': true, ',': true, ':': true,
 }
 
-var fm = map[rune]func(*context) (jsonToken, error) {
+func isSpace(r rune) bool {
+    return r >= 0 && r < utf8.RuneSelf && whiteSpace[r]
+}
+
+func isDelimiter(r rune) bool {
+    return r >= 0 && r < utf8.RuneSelf && delimiters[r]
+}
+
+var fm = [utf8.RuneSelf]func(*context) (jsonToken, error) {
     't': tokenizeBoolean,
     'f': tokenizeBoolean,
     'n': tokenizeNull,
@@ -56,7 +111,7 @@ var fm = map[rune]func(*context) (jsonToken, error) {
     '9': tokenizeNumber,
 }
 
-var tm = map[rune] tokenType {
+var tm = [utf8.RuneSelf]TokenType {
     '{': LeftBrace,
     '}': RightBrace,
     '[': LeftBracket,
@@ -65,82 +120,250 @@ var tm = map[rune] tokenType {
     ',': Comma,
 }
 
+// context reads the utf-8 source directly. columns are not counted as runes are read,
+// they are worked out from the byte offset in the line when a position is asked for
 type context struct {
-    rs      []rune
-    cursor  int
-    lineNum int
-    colNum  int
+    src       string
+    cursor    int  // byte offset of the next rune
+    size      int  // bytes of the last rune read, back steps over them
+    wide      int  // the last rune read takes size-wide columns
+    line      int
+    lineStart int  // byte offset of the first byte of the line
+    extra     int  // bytes of the line read so far which take no column of their own
+    utf16     bool // see Options.UTF16Columns
+    replace   bool // see Options.ReplaceInvalid
+}
+
+func newContext(source string, opts Options) context {
+    return context{src: source, line: 1, utf16: opts.UTF16Columns, replace: opts.ReplaceInvalid}
 }
 
+// invalidRune stands for a byte of the source which is not valid utf-8, it is
+// told apart from a real U+FFFD (utf8.RuneError)
+const invalidRune rune = -1
+
+var overstep = errors.New("overstep")
+
 func getNextRune(ctx *context) (rune, error) {
-    var r rune
-    if ctx.cursor < len(ctx.rs) {
-        r = ctx.rs[ctx.cursor]
-        ctx.cursor++
-        ctx.colNum++
-        return r, nil
+    if ctx.cursor >= len(ctx.src) {
+        return 0, overstep
     }
-    return r, errors.New("overstep")
+    var r, size = rune(ctx.src[ctx.cursor]), 1
+    ctx.wide = 1
+    if r >= utf8.RuneSelf {
+        r, size = utf8.DecodeRuneInString(ctx.src[ctx.cursor:])
+        if r == utf8.RuneError && size == 1 { r = invalidRune }
+        if ctx.utf16 && r > 0xFFFF { ctx.wide = 2 } // a surrogate pair
+        ctx.extra += size - ctx.wide
+    }
+    ctx.cursor += size
+    ctx.size = size
+    return r, nil
 }
 
 func back(ctx *context) {
-    ctx.cursor--
-    ctx.colNum--
+    ctx.cursor -= ctx.size
+    ctx.extra -= ctx.size - ctx.wide
 }
 
-// 将json字符串解析成token流
-func tokenize(source string) ([]jsonToken, []jsonError) {
-    var ctx = context {
-        rs:      []rune(source),
-        cursor:  0,
-        lineNum: 1,
-        colNum:  1,
+// newLine is called when the '\n' ending a line is read
+func (ctx *context) newLine() {
+    ctx.line++
+    ctx.lineStart, ctx.extra = ctx.cursor, 0
+}
+
+// position is where the next rune will be read from
+func (ctx *context) position() Position {
+    return Position{ctx.cursor, ctx.line, 1 + ctx.cursor - ctx.lineStart - ctx.extra}
+}
+
+func (ctx *context) location() location {
+    return location{ctx.line, 1 + ctx.cursor - ctx.lineStart - ctx.extra}
+}
+
+// columns is the width of text in runes, or in UTF-16 code units,
+// every byte of invalid utf-8 takes one column
+func columns(text string, utf16 bool) int {
+    var n = 0
+    for i := 0; i < len(text); {
+        if text[i] < utf8.RuneSelf {
+            n++
+            i++
+            continue
+        }
+        r, size := utf8.DecodeRuneInString(text[i:])
+        n++
+        if utf16 && r > 0xFFFF { n++ } // a surrogate pair
+        i += size
     }
+    return n
+}
 
-    var jts []jsonToken
-    var jerrs []jsonError
+// Scanner reads the tokens of a json text one at a time
+type Scanner struct {
+    source  string
+    ctx     context
+    opts    Options
+    count   int  // tokens returned, counted for MaxTokens
+    stopped bool // a limit was exceeded
+}
 
-    var doTokenizeSingle = func(r rune) {
-        token := jsonToken{ tm[r], string(r), location{ctx.lineNum, ctx.colNum - 1 } }
-        jts = append(jts, token)
+// NewScanner returns a Scanner reading the tokens of source
+func NewScanner(source string) *Scanner {
+    return NewScannerWithOptions(source, Options{})
+}
+
+// NewScannerWithOptions returns a Scanner reading the tokens of source as opts says
+func NewScannerWithOptions(source string, opts Options) *Scanner {
+    return &Scanner{
+        source: source,
+        ctx:    newContext(source, opts),
+        opts:   opts,
     }
+}
 
-    var doTokenizeCall = func(r rune) {
-        token, jerr := fm[r](&ctx)
-        if jerr != nil { jerrs = append(jerrs, jerr.(jsonError)) }
-        jts = append(jts, token)
+// newScannerAt returns a Scanner reading source from pos on, pos is where the token starts
+// in the whole text. the limits of opts are counted from pos
+func newScannerAt(source string, opts Options, pos Position) *Scanner {
+    var s = NewScannerWithOptions(source, opts)
+    s.ctx.cursor, s.ctx.line, s.ctx.lineStart = pos.Offset, pos.Line, pos.Offset-pos.Column+1
+    return s
+}
+
+// Next returns the next token, or io.EOF at the end of the source.
+// text which can not be tokenized is returned as an Invalid token together
+// with the error describing it, scanning can go on after it.
+// exceeding a limit of the Options stops the scanning: the error
+// (TooLarge, TooManyTokens or StringTooLong) is returned and then io.EOF
+func (s *Scanner) Next() (Token, error) {
+    if s.stopped {
+        return Token{}, io.EOF
+    }
+    if s.ctx.cursor == 0 && s.opts.MaxBytes > 0 && len(s.source) > s.opts.MaxBytes {
+        s.stopped = true
+        return Token{}, jsonError{TooLarge, locationOf(s.source, s.opts.MaxBytes, s.opts.UTF16Columns)}
     }
 
-    var r rune
-    var err error
-    for r, err = getNextRune(&ctx); err == nil; r, err = getNextRune(&ctx) {
+    if s.opts.MaxTokens <= 0 && s.opts.MaxStringLength <= 0 {
+        return s.scan()
+    }
+
+    token, err := s.scan()
+    if err == io.EOF {
+        return token, err
+    }
+
+    s.count++
+    var start = location{token.Span.Start.Line, token.Span.Start.Column}
+    if s.opts.MaxTokens > 0 && s.count > s.opts.MaxTokens {
+        s.stopped = true
+        return Token{}, jsonError{TooManyTokens, start}
+    }
+    if s.opts.MaxStringLength > 0 && token.Typ == String && len(token.Val)-2 > s.opts.MaxStringLength {
+        s.stopped = true
+        return Token{}, jsonError{StringTooLong, start}
+    }
+    return token, err
+}
+
+// locationOf is the location of the byte at offset in source
+func locationOf(source string, offset int, utf16 bool) location {
+    var line = 1 + strings.Count(source[:offset], "\n")
+    var lineStart = strings.LastIndexByte(source[:offset], '\n') + 1
+    return location{line, 1 + columns(source[lineStart:offset], utf16)}
+}
+
+func (s *Scanner) scan() (Token, error) {
+    var ctx = &s.ctx
+    for {
+        var start = ctx.position()
+        r, err := getNextRune(ctx)
+        if err != nil {
+            return Token{}, io.EOF
+        }
+
         switch {
         case r == '{': fallthrough
         case r == '}': fallthrough
         case r == '[': fallthrough
         case r == ']': fallthrough
         case r == ':': fallthrough
-        case r == ',': doTokenizeSingle(r)
+        case r == ',': return s.token(tm[r], start), nil
 
         case r == 't' || r == 'f':  fallthrough
         case r == 'n':              fallthrough
         case r == '"':              fallthrough
         case unicode.IsDigit(r) || r == '+' || r == '-':
-            back(&ctx) // back to the first rune of the token
-            doTokenizeCall(r)
-
-        case whiteSpace[r]:
-            if r == '\n' {
-                ctx.lineNum++
-                ctx.colNum = 1
+            back(ctx) // back to the first rune of the token
+            var tokenizeFunc = tokenizeNumber // non-ascii digits, reported as NonASCIIDigit
+            if r < utf8.RuneSelf { tokenizeFunc = fm[r] }
+            token, jerr := tokenizeFunc(ctx)
+            if jerr != nil {
+                return s.token(Invalid, start), jerr
             }
+            return Token{token.Typ, token.Val, Span{start, ctx.position()}}, nil
+
+        case isSpace(r):
+            if r == '\n' { ctx.newLine() }
         default:
-            var col = ctx.colNum - 1
-            for r, err = getNextRune(&ctx); err == nil && !delimiters[r]; r, err = getNextRune(&ctx) { }
-            jerr := jsonError{InvalidToken, location {ctx.lineNum,col } }
-            jerrs = append(jerrs, jerr)
-            if err == nil { back(&ctx) } // back to the delimiter
+            for r, err = getNextRune(ctx); err == nil && !isDelimiter(r); r, err = getNextRune(ctx) { }
+            if err == nil { back(ctx) } // back to the delimiter
+            return s.token(Invalid, start), jsonError{InvalidToken, location{start.Line, start.Column}}
+        }
+    }
+}
+
+// token builds the token scanned from start up to the current position
+func (s *Scanner) token(typ TokenType, start Position) Token {
+    var end = s.ctx.position()
+    return Token{typ, s.source[start.Offset:end.Offset], Span{start, end}}
+}
+
+func (token Token) jsonToken() jsonToken {
+    var start, end = token.Span.Start, token.Span.End
+    return jsonToken{token.Typ, token.Val, location{start.Line, start.Column}, location{end.Line, end.Column}}
+}
+
+// Tokenize splits the json text into tokens, invalid tokens are reported as errors and skipped
+func Tokenize(source string) ([]Token, []jsonError) {
+    return scanAll(NewScanner(source))
+}
+
+func scanAll(s *Scanner) ([]Token, []jsonError) {
+    var tokens []Token
+    var jerrs []jsonError
+
+    for {
+        token, err := s.Next()
+        if err == io.EOF { break }
+        if err != nil {
+            jerrs = append(jerrs, err.(jsonError))
+            continue
         }
+        tokens = append(tokens, token)
+    }
+
+    return tokens, jerrs
+}
+
+// 将json字符串解析成token流
+func tokenize(source string) ([]jsonToken, []jsonError) {
+    return tokenizeWithOptions(source, Options{})
+}
+
+func tokenizeWithOptions(source string, opts Options) ([]jsonToken, []jsonError) {
+    var s = NewScannerWithOptions(source, opts)
+    var jts []jsonToken
+    var jerrs []jsonError
+
+    for {
+        token, err := s.Next()
+        if err == io.EOF { break }
+        if err != nil {
+            jerrs = append(jerrs, err.(jsonError))
+            continue
+        }
+        jts = append(jts, token.jsonToken())
     }
 
     return jts, jerrs
@@ -160,7 +383,7 @@ func tokenizeBoolean(ctx *context) (jsonToken, error) {
     )
 
     var start = ctx.cursor
-    var col = ctx.colNum
+    var loc = ctx.location()
     var stage = Initial
     var goPanic = false
     var r rune
@@ -181,7 +404,7 @@ loop:
         case Fa:	if r == 'l' { stage = Fal } else { goPanic = true; break loop }
         case Fal:	if r == 's' { stage = Fals } else { goPanic = true; break loop }
         case Fals:	if r == 'e' { stage = Acc } else { goPanic = true; break loop }
-        case Acc:   if !delimiters[r] { goPanic = true }; break loop
+        case Acc:   if !isDelimiter(r) { goPanic = true }; break loop
         }
     }
 
@@ -189,18 +412,18 @@ loop:
         if err == nil { back(ctx) } // back to the delimiter
         var token = jsonToken {
             Typ: Boolean,
-            Val: string(ctx.rs[start:ctx.cursor]),
-            Loc: location{ctx.lineNum, col},
+            Val: ctx.src[start:ctx.cursor],
+            Loc: loc,
         }
         return token, nil
     }
 
     if goPanic {
         back(ctx) // back to the rune which caused the panic
-        for r, err = getNextRune(ctx); err == nil && !delimiters[r]; r, err = getNextRune(ctx) { }
+        for r, err = getNextRune(ctx); err == nil && !isDelimiter(r); r, err = getNextRune(ctx) { }
         if err == nil { back(ctx) } // back to the delimiter
     }
-    var jerr = jsonError{ InvalidToken, location{ ctx.lineNum, col } }
+    var jerr = jsonError{ InvalidToken, loc }
     return jsonToken{}, jerr
 }
 
@@ -215,7 +438,7 @@ func tokenizeNull(ctx *context) (jsonToken, error) {
 
     var stage = Initial
     var start = ctx.cursor
-    var col = ctx.colNum
+    var loc = ctx.location()
     var goPanic = false
     var r rune
     var err error
@@ -227,7 +450,7 @@ loop:
         case N: 		if r == 'u' { stage = Nu } else { goPanic = true; break loop }
         case Nu: 		if r == 'l' { stage = Nul } else { goPanic = true; break loop }
         case Nul:		if r == 'l' { stage = Acc } else { goPanic = true; break loop }
-        case Acc:       if !delimiters[r] { goPanic = true }; break loop
+        case Acc:       if !isDelimiter(r) { goPanic = true }; break loop
         }
     }
 
@@ -235,18 +458,18 @@ loop:
         if err == nil { back(ctx) } // back to the delimiter
         var token = jsonToken {
             Typ: Null,
-            Val: string(ctx.rs[start:ctx.cursor]),
-            Loc: location{ctx.lineNum, col},
+            Val: ctx.src[start:ctx.cursor],
+            Loc: loc,
         }
         return token, nil
     }
 
     if goPanic {
         back(ctx) // back to the rune which caused the panic
-        for r, err = getNextRune(ctx); err == nil && !delimiters[r]; r, err = getNextRune(ctx) { }
+        for r, err = getNextRune(ctx); err == nil && !isDelimiter(r); r, err = getNextRune(ctx) { }
         if err == nil { back(ctx) } // back to the delimiter
     }
-    var jerr = jsonError{ InvalidToken, location{ ctx.lineNum, col } }
+    var jerr = jsonError{ InvalidToken, loc }
     return jsonToken{}, jerr
 }
 
@@ -265,7 +488,7 @@ func tokenizeNumber(ctx *context) (jsonToken, error) {
 
     var stage = Initial
     var start = ctx.cursor
-    var col = ctx.colNum
+    var loc = ctx.location()
     var goPanic = false
     var r rune
     var err error
@@ -276,43 +499,43 @@ loop:
         case Initial:
             if r == '0' { stage = Zero; continue }
             if r == '-' { stage = Neg; continue }
-            if unicode.IsDigit(r) { stage = Integer; continue }
+            if isDigit(r) { stage = Integer; continue }
             goPanic = true
             break loop
         case Zero:
             if r == '.' { stage = Dot; continue }
             if r == 'e' || r == 'E' { stage = E; continue }
-            if delimiters[r] { break loop } else { goPanic = true; break loop }
+            if isDelimiter(r) { break loop } else { goPanic = true; break loop }
         case Neg:
             if r == '0' { stage = Zero; continue }
-            if unicode.IsDigit(r) { stage = Integer; continue }
+            if isDigit(r) { stage = Integer; continue }
             goPanic = true
             break loop
         case Integer:
-            if unicode.IsDigit(r) { stage = Integer; continue }
+            if isDigit(r) { stage = Integer; continue }
             if r == '.' { stage = Dot; continue }
             if r == 'e' || r == 'E' { stage = E; continue }
-            if delimiters[r] { break loop } else { goPanic = true; break loop }
+            if isDelimiter(r) { break loop } else { goPanic = true; break loop }
         case Dot:
-            if unicode.IsDigit(r) { stage = Frac; continue }
+            if isDigit(r) { stage = Frac; continue }
             goPanic = true
             break loop
         case Frac:
-            if unicode.IsDigit(r) { stage = Frac; continue }
+            if isDigit(r) { stage = Frac; continue }
             if r == 'e' || r == 'E' { stage = E; continue }
-            if delimiters[r] { break loop } else { goPanic = true; break loop }
+            if isDelimiter(r) { break loop } else { goPanic = true; break loop }
         case E:
             if r == '-' || r == '+' { stage = ESign; continue }
-            if unicode.IsDigit(r) { stage = Exp; continue }
+            if isDigit(r) { stage = Exp; continue }
             goPanic = true
             break loop
         case ESign:
-            if unicode.IsDigit(r) { stage = Exp; continue }
+            if isDigit(r) { stage = Exp; continue }
             goPanic = true
             break loop
         case Exp:
-            if unicode.IsDigit(r) { stage = Exp; continue }
-            if delimiters[r] { break loop } else { goPanic = true; break loop }
+            if isDigit(r) { stage = Exp; continue }
+            if isDelimiter(r) { break loop } else { goPanic = true; break loop }
         }
     }
 
@@ -320,16 +543,22 @@ loop:
         if err == nil { back(ctx) } // back to the delimiter
         var token = jsonToken {
             Typ: Number,
-            Val: string(ctx.rs[start:ctx.cursor]),
-            Loc: location{ctx.lineNum, col},
+            Val: ctx.src[start:ctx.cursor],
+            Loc: loc,
         }
         return token, nil
     }
 
     var errType ErrorType
-    if stage == Dot && (err != nil || delimiters[r]) {
+    if goPanic && stage == Initial && r == '+' {
+        errType = LeadingPlus
+    } else if goPanic && stage == Zero && isDigit(r) {
+        errType = LeadingZero
+    } else if goPanic && unicode.IsDigit(r) {
+        errType = NonASCIIDigit
+    } else if stage == Dot && (err != nil || isDelimiter(r)) {
         errType = MissFracPart
-    } else if (stage == E || stage == ESign) && (err != nil || delimiters[r]) {
+    } else if (stage == E || stage == ESign) && (err != nil || isDelimiter(r)) {
         errType = MissExponentPart
     } else {
         errType = InvalidToken
@@ -337,10 +566,10 @@ loop:
 
     if goPanic {
         back(ctx) // back to the rune which caused the panic
-        for r, err = getNextRune(ctx); err == nil && !delimiters[r]; r, err = getNextRune(ctx) { }
+        for r, err = getNextRune(ctx); err == nil && !isDelimiter(r); r, err = getNextRune(ctx) { }
         if err == nil { back(ctx) } // back to the delimiter
     }
-    var jerr error = jsonError{ errType, location{ ctx.lineNum,col } }
+    var jerr error = jsonError{ errType, loc }
     return jsonToken{}, jerr
 }
 
@@ -358,27 +587,55 @@ func tokenizeString(ctx *context) (jsonToken, error) {
 
     var stage = Initial
     var start = ctx.cursor
-    var col = ctx.colNum
+    var loc = ctx.location()
     var isClose = false
     var goPanic = false
     var r rune
     var err error
 
+    var unit rune   // code unit of the \u escape being read
+    var high = -1   // where the escape of a high surrogate waiting for its low half starts
+    var lone []int  // where the escapes of lone surrogates start
+    var invalid []ErrorType // InvalidUTF8 and LoneSurrogate, the string is lexed to its end before reporting them
+    var loneHigh = func() {
+        if high < 0 { return }
+        lone = append(lone, high)
+        invalid = append(invalid, LoneSurrogate)
+        high = -1
+    }
+    var escaped = func() {
+        var at = ctx.cursor - 6 // the backslash
+        if high >= 0 && unit >= 0xDC00 && unit <= 0xDFFF {
+            high = -1
+            return
+        }
+        loneHigh()
+        if unit >= 0xD800 && unit <= 0xDBFF {
+            high = at
+        } else if unit >= 0xDC00 && unit <= 0xDFFF {
+            lone = append(lone, at)
+            invalid = append(invalid, LoneSurrogate)
+        }
+    }
+
 loop:
     for r, err = getNextRune(ctx); err == nil; r, err = getNextRune(ctx) {
         switch stage {
         case Initial: 	if r == '"' { stage = Open } else { goPanic = true; break loop }
-        case Unicode: 	if isHex(r) { stage = Hex } else { goPanic = true; break loop }
-        case Hex: 		if isHex(r) { stage = HexHex } else { goPanic = true; break loop }
-        case HexHex: 	if isHex(r) { stage = HexHexHex } else { goPanic = true; break loop }
-        case HexHexHex: if isHex(r) { stage = Open } else { goPanic = true; break loop }
+        case Unicode: 	if isHex(r) { stage = Hex; unit = hexValue(r) } else { goPanic = true; break loop }
+        case Hex: 		if isHex(r) { stage = HexHex; unit = unit<<4 | hexValue(r) } else { goPanic = true; break loop }
+        case HexHex: 	if isHex(r) { stage = HexHexHex; unit = unit<<4 | hexValue(r) } else { goPanic = true; break loop }
+        case HexHexHex: if isHex(r) { stage = Open; unit = unit<<4 | hexValue(r); escaped() } else { goPanic = true; break loop }
         case Open:
+            if r != '\\' { loneHigh() }
             if r == '"' { isClose = true; stage = Acc; break loop }
             if r == '\\' { stage = Escape; continue }
-            if r >= 0x0020 && r <= 0x10FFF && !unicode.IsControl(r) { stage = Open; continue }
+            if r == invalidRune { invalid = append(invalid, InvalidUTF8); continue }
+            if r >= 0x0020 && r <= 0x10FFFF { stage = Open; skipPlain(ctx); continue }
             goPanic = true
             break loop
         case Escape:
+            if r != 'u' { loneHigh() }
             if r == 'u' { stage = Unicode; continue }
             if isEscapable(r) { stage = Open; continue }
             goPanic = true
@@ -386,14 +643,18 @@ loop:
         }
     }
 
-    if stage == Acc {
+    if stage == Acc && (len(invalid) == 0 || ctx.replace) {
         var token = jsonToken {
             Typ: String,
-            Val: string(ctx.rs[start:ctx.cursor]),
-            Loc: location{ctx.lineNum, col},
+            Val: ctx.src[start:ctx.cursor],
+            Loc: loc,
         }
+        if len(invalid) != 0 { token.Val = replaceInvalid(token.Val, lone, start) }
         return token, nil
     }
+    if stage == Acc {
+        return jsonToken{}, jsonError{ invalid[0], loc }
+    }
 
     if goPanic {
         back(ctx) // back to the rune which caused the panic
@@ -419,14 +680,91 @@ loop:
     } else if stage == Open {
         errTyp = InvalidChar
     }
-    var jerr = jsonError{ errTyp, location{ ctx.lineNum, col } }
+    var jerr = jsonError{ errTyp, loc }
     return jsonToken{}, jerr
 }
 
+// skipPlain skips the ascii characters of a string which need no checking
+func skipPlain(ctx *context) {
+    for ctx.cursor < len(ctx.src) {
+        var c = ctx.src[ctx.cursor]
+        if c < 0x20 || c >= utf8.RuneSelf || c == '"' || c == '\\' { return }
+        ctx.cursor++
+    }
+}
+
+// skipContainer skips to just past the brace or bracket closing the container whose
+// opening one was the last token read, and returns the position there. only strings and
+// brackets are looked at, so the text must be known to be valid json
+func (s *Scanner) skipContainer() Position {
+    var ctx = &s.ctx
+    var from = ctx.cursor // start of the text whose columns are not counted yet
+    for depth := 1; depth > 0; {
+        var c = ctx.src[ctx.cursor]
+        ctx.cursor++
+        switch c {
+        case '{', '[':
+            depth++
+        case '}', ']':
+            depth--
+        case '\n':
+            ctx.extra += ctx.cursor - 1 - from - columns(ctx.src[from:ctx.cursor-1], ctx.utf16)
+            ctx.newLine()
+            from = ctx.cursor
+        case '"':
+            for {
+                ctx.cursor += strings.IndexByte(ctx.src[ctx.cursor:], '"') + 1
+                // the quote ends the string unless an odd number of backslashes escapes it
+                var n = 0
+                for ctx.src[ctx.cursor-2-n] == '\\' { n++ }
+                if n%2 == 0 { break }
+            }
+        }
+    }
+    ctx.extra += ctx.cursor - from - columns(ctx.src[from:ctx.cursor], ctx.utf16)
+    return ctx.position()
+}
+
+// replaceInvalid turns the string token into text, the escapes of lone surrogates starting
+// at the byte offsets in lone are replaced with \ufffd and every byte of invalid utf-8 with U+FFFD
+func replaceInvalid(text string, lone []int, start int) string {
+    var sb strings.Builder
+    sb.Grow(len(text))
+    for i := 0; i < len(text); {
+        if len(lone) != 0 && i == lone[0]-start {
+            sb.WriteString(`\ufffd`)
+            lone = lone[1:]
+            i += 6
+            continue
+        }
+        r, size := utf8.DecodeRuneInString(text[i:])
+        if r == utf8.RuneError && size == 1 {
+            sb.WriteRune(utf8.RuneError)
+        } else {
+            sb.WriteString(text[i:i+size])
+        }
+        i += size
+    }
+    return sb.String()
+}
+
+// isDigit reports whether r is an ascii digit, the only digits json allows
+func isDigit(r rune) bool {
+    return r >= '0' && r <= '9'
+}
+
 func isHex(r rune) bool {
-    return unicode.IsDigit(r) || r >= 'A' && r <= 'F' || r >= 'a' && r <= 'f'
+    return isDigit(r) || r >= 'A' && r <= 'F' || r >= 'a' && r <= 'f'
+}
+
+func hexValue(r rune) rune {
+    switch {
+    case r >= 'a': return r - 'a' + 10
+    case r >= 'A': return r - 'A' + 10
+    }
+    return r - '0'
 }
 
 func isEscapable(r rune) bool {
-    return r == '\\' || r == 'b' || r == 'f' || r == 'n' || r == 'r' || r == 't' || r == '"' || r == 'u'
+    return r == '\\' || r == 'b' || r == 'f' || r == 'n' || r == 'r' || r == 't' || r == '"' || r == '/' || r == 'u'
 }
\ No newline at end of file
@@ -1,6 +1,12 @@
 package json2ast
 
 import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
     "testing"
 )
 
@@ -27,12 +33,7 @@ func TestTokenizeBoolean(t *testing.T) {
 	}
 
 	for _, js := range jsonStrs {
-        var ctx = context {
-            rs:      []rune(js),
-            cursor:  0,
-            lineNum: 1,
-            colNum:  1,
-        }
+        var ctx = newContext(js, Options{})
 		token, err := tokenizeBoolean(&ctx)
 		t.Log(token, err, ctx)
 	}
@@ -61,12 +62,7 @@ func TestTokenizeNull(t *testing.T) {
 	}
 
 	for _, js := range jsonStrs {
-        var ctx = context {
-            rs:      []rune(js),
-            cursor:  0,
-            lineNum: 1,
-            colNum:  1,
-        }
+        var ctx = newContext(js, Options{})
         token, err := tokenizeNull(&ctx)
         t.Log(token, err, ctx)
 	}
@@ -153,23 +149,13 @@ func TestTokenizeNumber(t *testing.T) {
 	}
 
 	for _, js := range validTests {
-        var ctx = context {
-            rs:      []rune(js),
-            cursor:  0,
-            lineNum: 1,
-            colNum:  1,
-        }
+        var ctx = newContext(js, Options{})
         token, err := tokenizeNumber(&ctx)
         t.Log(token, err, ctx)
 	}
 
 	for _, js := range invalidTests {
-        var ctx = context {
-            rs:      []rune(js),
-            cursor:  0,
-            lineNum: 1,
-            colNum:  1,
-        }
+        var ctx = newContext(js, Options{})
         token, err := tokenizeNumber(&ctx)
         t.Log(token, err, ctx)
 	}
@@ -200,23 +186,13 @@ func TestTokenizeString(t *testing.T) {
     }
 
     for _, js := range validTests {
-        var ctx = context {
-            rs:      []rune(js),
-            cursor:  0,
-            lineNum: 1,
-            colNum:  1,
-        }
+        var ctx = newContext(js, Options{})
         token, err := tokenizeString(&ctx)
         t.Log(token, err, ctx)
     }
 
     for _, js := range invalidTests {
-        var ctx = context {
-            rs:      []rune(js),
-            cursor:  0,
-            lineNum: 1,
-            colNum:  1,
-        }
+        var ctx = newContext(js, Options{})
         token, err := tokenizeString(&ctx)
         t.Log(token, err, ctx)
     }
@@ -343,4 +319,255 @@ func TestTokenize(t *testing.T) {
     for _, v := range jtks {
         t.Logf("%+v\n", v)
     }
-}
\ No newline at end of file
+}
+
+func TestScanner(t *testing.T) {
+    type want struct {
+        typ        TokenType
+        val        string
+        start, end Position
+        errTyp     ErrorType
+    }
+    var source = "{\"é\": [1,\n  tru, \"x\"]}"
+    var wants = []want {
+        {LeftBrace, "{", Position{0, 1, 1}, Position{1, 1, 2}, 0},
+        {String, "\"é\"", Position{1, 1, 2}, Position{5, 1, 5}, 0},
+        {Colon, ":", Position{5, 1, 5}, Position{6, 1, 6}, 0},
+        {LeftBracket, "[", Position{7, 1, 7}, Position{8, 1, 8}, 0},
+        {Number, "1", Position{8, 1, 8}, Position{9, 1, 9}, 0},
+        {Comma, ",", Position{9, 1, 9}, Position{10, 1, 10}, 0},
+        {Invalid, "tru", Position{13, 2, 3}, Position{16, 2, 6}, InvalidToken},
+        {Comma, ",", Position{16, 2, 6}, Position{17, 2, 7}, 0},
+        {String, "\"x\"", Position{18, 2, 8}, Position{21, 2, 11}, 0},
+        {RightBracket, "]", Position{21, 2, 11}, Position{22, 2, 12}, 0},
+        {RightBrace, "}", Position{22, 2, 12}, Position{23, 2, 13}, 0},
+    }
+
+    var s = NewScanner(source)
+    for _, w := range wants {
+        token, err := s.Next()
+        if token.Typ != w.typ || token.Val != w.val || token.Span != (Span{w.start, w.end}) {
+            t.Fatalf("got token %+v, want %+v", token, w)
+        }
+        if w.typ == Invalid {
+            if jerr, ok := err.(jsonError); !ok || jerr.typ != w.errTyp {
+                t.Fatalf("token %+v gives error %v, want %v", token, err, w.errTyp)
+            }
+        } else if err != nil {
+            t.Fatalf("token %+v gives error %v", token, err)
+        }
+        if source[token.Span.Start.Offset:token.Span.End.Offset] != token.Val {
+            t.Fatalf("span %+v does not cover %q", token.Span, token.Val)
+        }
+    }
+
+    if _, err := s.Next(); err != io.EOF {
+        t.Fatalf("got %v at the end, want io.EOF", err)
+    }
+}
+
+// TestNumberConformance checks the number lexer against the RFC 8259 grammar:
+// number = [ minus ] int [ frac ] [ exp ], on ascii digits only
+func TestNumberConformance(t *testing.T) {
+    var valid = []string {
+        "0", "-0", "1", "-1", "9", "10", "1234567890", "-1234567890",
+        "0.0", "-0.0", "0.5", "12.34", "-12.340",
+        "0e0", "0E0", "0e+0", "0e-0", "1e5", "1E5", "1e+5", "1e-5", "1E+05",
+        "1.5e10", "-1.5E-10", "123456789012345678901234567890", "1e400",
+    }
+    var invalid = map[string]ErrorType {
+        "01": LeadingZero,
+        "00": LeadingZero,
+        "-01": LeadingZero,
+        "00.5": LeadingZero,
+        "+1": LeadingPlus,
+        "+0.5": LeadingPlus,
+        "١٢": NonASCIIDigit, // arabic-indic
+        "1٢": NonASCIIDigit,
+        "-١": NonASCIIDigit,
+        "1.٢": NonASCIIDigit,
+        "1e٢": NonASCIIDigit,
+        "１": NonASCIIDigit, // full-width
+        "१२": NonASCIIDigit, // devanagari
+        "1.": MissFracPart,
+        "-0.": MissFracPart,
+        "1e": MissExponentPart,
+        "1E+": MissExponentPart,
+        "1e-": MissExponentPart,
+        "-": InvalidToken,
+        "--1": InvalidToken,
+        "-.5": InvalidToken,
+        ".5": InvalidToken,
+        "1.e3": InvalidToken,
+        "1.5.2": InvalidToken,
+        "0x10": InvalidToken,
+        "1a": InvalidToken,
+        "Infinity": InvalidToken,
+        "-Infinity": InvalidToken,
+        "NaN": InvalidToken,
+    }
+
+    for _, v := range valid {
+        tokens, jerrs := Tokenize(v)
+        if len(jerrs) != 0 || len(tokens) != 1 || tokens[0].Typ != Number || tokens[0].Val != v {
+            t.Errorf("`%s` gives tokens %v and errors %v, want one number", v, tokens, jerrs)
+        }
+    }
+    for v, want := range invalid {
+        tokens, jerrs := Tokenize(v)
+        if len(tokens) != 0 || len(jerrs) != 1 || jerrs[0].typ != want || jerrs[0].loc != (location{1, 1}) {
+            t.Errorf("`%s` gives tokens %v and errors %v, want %v at [1, 1]", v, tokens, jerrs, want)
+        }
+    }
+
+    // lexing goes on after an invalid number
+    tokens, jerrs := Tokenize("[01, +2, 3]")
+    if len(tokens) != 5 || tokens[3].Val != "3" || len(jerrs) != 2 ||
+        jerrs[0] != (jsonError{LeadingZero, location{1, 2}}) || jerrs[1] != (jsonError{LeadingPlus, location{1, 6}}) {
+        t.Errorf("`[01, +2, 3]` gives tokens %v and errors %v", tokens, jerrs)
+    }
+}
+
+func TestStringUnicode(t *testing.T) {
+    var valid = []string {
+        "\"😀\"", "\"\U0010FFFF\"", "\"\x7f\u0085\"", "\"�\"",
+        `"\ud83d\ude00"`, `"\uD83D\uDE00A"`, `"\uffff"`,
+    }
+    var invalid = map[string]ErrorType {
+        `"\ud800"`: LoneSurrogate,
+        `"\udc00"`: LoneSurrogate,
+        `"\ud800A"`: LoneSurrogate,
+        `"\ud800x"`: LoneSurrogate,
+        `"\ud800\n"`: LoneSurrogate,
+        `"\ud800\ud800"`: LoneSurrogate,
+        `"\ude00\ud83d"`: LoneSurrogate,
+        "\"a\xffb\"": InvalidUTF8,
+        "\"\xed\xa0\x80\"": InvalidUTF8, // utf-8 encoded surrogate
+        "\"\xc0\xaf\"": InvalidUTF8, // overlong
+        "\"\xf4\x90\x80\x80\"": InvalidUTF8, // beyond U+10FFFF
+        "\"\xff\\ud800\"": InvalidUTF8,
+    }
+
+    for _, v := range valid {
+        tokens, jerrs := Tokenize(v)
+        if len(jerrs) != 0 || len(tokens) != 1 || tokens[0].Val != v {
+            t.Errorf("`%s` gives tokens %v and errors %v, want one string", v, tokens, jerrs)
+        }
+    }
+    for v, want := range invalid {
+        tokens, jerrs := Tokenize("[" + v + ", 1]")
+        if len(tokens) != 4 || len(jerrs) != 1 || jerrs[0] != (jsonError{want, location{1, 2}}) {
+            t.Errorf("`%s` gives tokens %v and errors %v, want %v at [1, 2]", v, tokens, jerrs, want)
+        }
+    }
+
+    var replaced = map[string]string {
+        `"\ud800x"`: `"\ufffdx"`,
+        `"\ud800\ud800\udc00"`: `"\ufffd\ud800\udc00"`,
+        `"a\udc00\ud800"`: `"a\ufffd\ufffd"`,
+        "\"a\xffb\"": "\"a�b\"",
+    }
+    for v, want := range replaced {
+        jts, jerrs := tokenizeWithOptions(v, Options{ReplaceInvalid: true})
+        if len(jerrs) != 0 || len(jts) != 1 || jts[0].Val != want {
+            t.Errorf("`%s` gives tokens %v and errors %v, want %s", v, jts, jerrs, want)
+        }
+    }
+}
+
+// TestScannerGolden lists the tokens and errors of every testdata/scanner/*.json in .tokens,
+// and with Options.ReplaceInvalid in .replaced
+func TestScannerGolden(t *testing.T) {
+    inputs, err := filepath.Glob(filepath.Join("testdata", "scanner", "*.json"))
+    if err != nil || len(inputs) == 0 {
+        t.Fatalf("no inputs in testdata/scanner: %v", err)
+    }
+
+    for _, in := range inputs {
+        source, err := os.ReadFile(in)
+        if err != nil {
+            t.Fatal(err)
+        }
+        var base = strings.TrimSuffix(in, ".json")
+        checkGolden(t, base+".tokens", dumpTokens(NewScanner(string(source))))
+        checkGolden(t, base+".replaced", dumpTokens(NewScannerWithOptions(string(source), Options{ReplaceInvalid: true})))
+    }
+}
+
+// dumpTokens lists the tokens with their spans, then the errors
+func dumpTokens(s *Scanner) string {
+    var sb strings.Builder
+    tokens, jerrs := scanAll(s)
+    for _, token := range tokens {
+        var start, end = token.Span.Start, token.Span.End
+        fmt.Fprintf(&sb, "%d:%d-%d:%d %d-%d %s %q\n", start.Line, start.Column, end.Line, end.Column, start.Offset, end.Offset, token.Typ, token.Val)
+    }
+    for _, jerr := range jerrs {
+        sb.WriteString(jerr.Error())
+        sb.WriteByte('\n')
+    }
+    return sb.String()
+}
+
+func TestScannerUTF16Columns(t *testing.T) {
+    var source = "[\"😀é\", 1,\n\"😀\"]"
+    var want = []struct{ start, end int }{{1, 2}, {2, 7}, {7, 8}, {9, 10}, {10, 11}, {1, 5}, {5, 6}}
+    var s = NewScannerWithOptions(source, Options{UTF16Columns: true})
+    for _, w := range want {
+        token, err := s.Next()
+        if err != nil || token.Span.Start.Column != w.start || token.Span.End.Column != w.end {
+            t.Fatalf("token %+v (%v), want columns %d-%d", token, err, w.start, w.end)
+        }
+    }
+
+    _, jerrs := ParserWithOptions("{\"😀\": x}", Options{UTF16Columns: true})
+    if len(jerrs) != 1 || jerrs[0] != (jsonError{InvalidToken, location{1, 8}}) {
+        t.Errorf("errors %v, want InvalidToken at [1, 8]", jerrs)
+    }
+}
+
+// benchmarkDocument is about 1MB of json with all kinds of tokens
+func benchmarkDocument() string {
+    var sb strings.Builder
+    sb.WriteString("[\n")
+    for i := 0; sb.Len() < 1<<20; i++ {
+        if i > 0 { sb.WriteString(",\n") }
+        sb.WriteString(`  {"id": 12345, "name": "json2ast \u00e9t\u00e9", "price": -1.25e-3, "tags": ["a", "b\"c", "日本語"],`)
+        sb.WriteString(`   "active": true, "parent": null, "nested": {"x": [1, 2, 3, {"y": false}], "z": "😀 emoji"}}`)
+    }
+    sb.WriteString("\n]\n")
+    return sb.String()
+}
+
+func BenchmarkTokenize(b *testing.B) {
+    var source = benchmarkDocument()
+
+    b.Run("bytes", func(b *testing.B) {
+        b.SetBytes(int64(len(source)))
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            var s = NewScanner(source)
+            for {
+                if _, err := s.Next(); err == io.EOF { break }
+            }
+        }
+    })
+    b.Run("encoding/json", func(b *testing.B) {
+        b.SetBytes(int64(len(source)))
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            var d = json.NewDecoder(strings.NewReader(source))
+            for {
+                if _, err := d.Token(); err != nil { break }
+            }
+        }
+    })
+    b.Run("encoding/json.Valid", func(b *testing.B) {
+        var data = []byte(source)
+        b.SetBytes(int64(len(source)))
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            json.Valid(data)
+        }
+    })
+}
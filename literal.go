@@ -0,0 +1,113 @@
+package json2ast
+
+import (
+    "errors"
+    "strconv"
+    "strings"
+    "unicode/utf16"
+    "unicode/utf8"
+)
+
+// unquote decodes the raw text of a String token (quotes included) into its value.
+// lone surrogates are replaced by U+FFFD
+func unquote(raw string) (string, error) {
+    if len(raw) < 2 || raw[0] != '"' || raw[len(raw)-1] != '"' {
+        return "", errors.New("invalid string literal: " + raw)
+    }
+
+    var s = raw[1 : len(raw)-1]
+    if strings.IndexByte(s, '\\') < 0 {
+        return s, nil
+    }
+
+    var sb strings.Builder
+    sb.Grow(len(s))
+    for i := 0; i < len(s); {
+        if s[i] != '\\' {
+            sb.WriteByte(s[i])
+            i++
+            continue
+        }
+        if i+1 >= len(s) {
+            return "", errors.New("invalid string literal: " + raw)
+        }
+
+        switch s[i+1] {
+        case '"':  sb.WriteByte('"')
+        case '\\': sb.WriteByte('\\')
+        case '/':  sb.WriteByte('/')
+        case 'b':  sb.WriteByte('\b')
+        case 'f':  sb.WriteByte('\f')
+        case 'n':  sb.WriteByte('\n')
+        case 'r':  sb.WriteByte('\r')
+        case 't':  sb.WriteByte('\t')
+        case 'u':
+            r, ok := decodeHex4(s[i+2:])
+            if !ok {
+                return "", errors.New("invalid string literal: " + raw)
+            }
+            i += 6
+            if utf16.IsSurrogate(r) {
+                var r2, ok = rune(-1), false
+                if i+1 < len(s) && s[i] == '\\' && s[i+1] == 'u' {
+                    r2, ok = decodeHex4(s[i+2:])
+                }
+                if ok && utf16.DecodeRune(r, r2) != utf8.RuneError {
+                    r = utf16.DecodeRune(r, r2)
+                    i += 6
+                } else {
+                    r = utf8.RuneError
+                }
+            }
+            sb.WriteRune(r)
+            continue
+        default:
+            return "", errors.New("invalid string literal: " + raw)
+        }
+        i += 2
+    }
+
+    return sb.String(), nil
+}
+
+func decodeHex4(s string) (rune, bool) {
+    if len(s) < 4 {
+        return 0, false
+    }
+    v, err := strconv.ParseUint(s[:4], 16, 32)
+    if err != nil {
+        return 0, false
+    }
+    return rune(v), true
+}
+
+// quote encodes s as a json string with the minimal escaping of RFC 8785:
+// only '"', '\\' and control characters are escaped
+func quote(s string) string {
+    const hex = "0123456789abcdef"
+
+    var sb strings.Builder
+    sb.Grow(len(s) + 2)
+    sb.WriteByte('"')
+    for i := 0; i < len(s); i++ {
+        var c = s[i]
+        switch {
+        case c == '"':  sb.WriteString(`\"`)
+        case c == '\\': sb.WriteString(`\\`)
+        case c == '\b': sb.WriteString(`\b`)
+        case c == '\f': sb.WriteString(`\f`)
+        case c == '\n': sb.WriteString(`\n`)
+        case c == '\r': sb.WriteString(`\r`)
+        case c == '\t': sb.WriteString(`\t`)
+        case c < 0x20:
+            sb.WriteString(`\u00`)
+            sb.WriteByte(hex[c>>4])
+            sb.WriteByte(hex[c&0xF])
+        default:
+            sb.WriteByte(c)
+        }
+    }
+    sb.WriteByte('"')
+
+    return sb.String()
+}
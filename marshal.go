@@ -0,0 +1,133 @@
+package json2ast
+
+import (
+    "bytes"
+    "encoding/json"
+    "sort"
+)
+
+// MarshalJSON implements json.Marshaler, the ast is written as compact json
+// with object members in document order
+func (ast JsonAst) MarshalJSON() ([]byte, error) {
+    var buf bytes.Buffer
+    writeCompact(&buf, ast)
+    return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, data is parsed with Parser
+func (ast *JsonAst) UnmarshalJSON(data []byte) error {
+    parsed, jerrs := Parser(string(data))
+    if len(jerrs) != 0 {
+        return jerrs[0]
+    }
+    *ast = parsed
+    return nil
+}
+
+// FormatIndent writes the ast as json with one member or element per line,
+// nested levels are indented by indent
+func FormatIndent(ast JsonAst, indent string) []byte {
+    var buf bytes.Buffer
+    writeIndent(&buf, ast, indent, 0)
+    return buf.Bytes()
+}
+
+// FromRawMessage parses the raw json into a JsonAst
+func FromRawMessage(raw json.RawMessage) (JsonAst, error) {
+    var ast JsonAst
+    if err := ast.UnmarshalJSON(raw); err != nil {
+        return JsonAst{}, err
+    }
+    return ast, nil
+}
+
+// ToRawMessage writes the ast as a compact json.RawMessage
+func ToRawMessage(ast JsonAst) (json.RawMessage, error) {
+    b, err := ast.MarshalJSON()
+    if err != nil {
+        return nil, err
+    }
+    return json.RawMessage(b), nil
+}
+
+// objectKeys returns the raw member names of the object in document order
+// when it is known, in lexical order otherwise
+func objectKeys(ast JsonAst) []string {
+    if len(ast.ObjectKeys) == len(ast.ObjectAst) {
+        return ast.ObjectKeys
+    }
+    return sortedKeys(ast)
+}
+
+// sortedKeys returns the raw member names of the object in lexical order
+func sortedKeys(ast JsonAst) []string {
+    var keys = make([]string, 0, len(ast.ObjectAst))
+    for k := range ast.ObjectAst {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
+
+func writeCompact(buf *bytes.Buffer, ast JsonAst) {
+    switch ast.Typ {
+    case Object:
+        buf.WriteByte('{')
+        for i, k := range objectKeys(ast) {
+            if i != 0 { buf.WriteByte(',') }
+            buf.WriteString(k)
+            buf.WriteByte(':')
+            writeCompact(buf, ast.ObjectAst[k])
+        }
+        buf.WriteByte('}')
+    case Array:
+        buf.WriteByte('[')
+        for i, v := range ast.ArrayAst {
+            if i != 0 { buf.WriteByte(',') }
+            writeCompact(buf, v)
+        }
+        buf.WriteByte(']')
+    case Literal:
+        buf.WriteString(ast.LiteralAst.Val)
+    }
+}
+
+func writeIndent(buf *bytes.Buffer, ast JsonAst, indent string, depth int) {
+    var newline = func(depth int) {
+        buf.WriteByte('\n')
+        for i := 0; i < depth; i++ { buf.WriteString(indent) }
+    }
+
+    switch ast.Typ {
+    case Object:
+        if len(ast.ObjectAst) == 0 {
+            buf.WriteString("{}")
+            return
+        }
+        buf.WriteByte('{')
+        for i, k := range objectKeys(ast) {
+            if i != 0 { buf.WriteByte(',') }
+            newline(depth + 1)
+            buf.WriteString(k)
+            buf.WriteString(": ")
+            writeIndent(buf, ast.ObjectAst[k], indent, depth+1)
+        }
+        newline(depth)
+        buf.WriteByte('}')
+    case Array:
+        if len(ast.ArrayAst) == 0 {
+            buf.WriteString("[]")
+            return
+        }
+        buf.WriteByte('[')
+        for i, v := range ast.ArrayAst {
+            if i != 0 { buf.WriteByte(',') }
+            newline(depth + 1)
+            writeIndent(buf, v, indent, depth+1)
+        }
+        newline(depth)
+        buf.WriteByte(']')
+    case Literal:
+        buf.WriteString(ast.LiteralAst.Val)
+    }
+}
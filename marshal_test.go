@@ -0,0 +1,71 @@
+package json2ast
+
+import (
+    "encoding/json"
+    "reflect"
+    "testing"
+)
+
+type marshalEnvelope struct {
+    Kind    string    `json:"kind"`
+    Payload JsonAst   `json:"payload"`
+    Extra   *JsonAst  `json:"extra,omitempty"`
+}
+
+func TestMarshalJSON(t *testing.T) {
+    validTests := []string {
+        `{"kind": "a", "payload": {"b": [1, 2.50, "xA"], "a": null}, "extra": true}`,
+        `{"kind": "b", "payload": [[], {}, -0.0e+1]}`,
+        `{"kind": "c", "payload": null}`,
+    }
+
+    for _, vt := range validTests {
+        var env marshalEnvelope
+        if err := json.Unmarshal([]byte(vt), &env); err != nil {
+            t.Fatalf("unmarshal `%s` failed: %v", vt, err)
+        }
+
+        b, err := json.Marshal(env)
+        if err != nil {
+            t.Fatalf("marshal `%s` failed: %v", vt, err)
+        }
+
+        var want, got interface{}
+        _ = json.Unmarshal([]byte(vt), &want)
+        _ = json.Unmarshal(b, &got)
+        if !reflect.DeepEqual(got, want) {
+            t.Fatalf("marshal `%s` gives `%s`", vt, b)
+        }
+
+        // Decode stores nodes into JsonAst fields as they are
+        ast, jerrs := Parser(vt)
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed", vt)
+        }
+        var decoded marshalEnvelope
+        if err = Decode(ast, &decoded); err != nil {
+            t.Fatalf("decode `%s` failed: %v", vt, err)
+        }
+        if !reflect.DeepEqual(decoded.Payload, ast.ObjectAst[`"payload"`]) {
+            t.Fatalf("decode `%s` gives payload %+v", vt, decoded.Payload)
+        }
+    }
+
+    raw := json.RawMessage(`{"k": [true, false]}`)
+    ast, err := FromRawMessage(raw)
+    if err != nil {
+        t.Fatalf("build AST for `%s` failed: %v", raw, err)
+    }
+    back, err := ToRawMessage(ast)
+    if err != nil || string(back) != `{"k":[true,false]}` {
+        t.Fatalf("raw message of `%s` is `%s` (%v)", raw, back, err)
+    }
+
+    var env marshalEnvelope
+    if err = json.Unmarshal([]byte(`{"payload": [1, }`), &env); err == nil {
+        t.Fatal("unmarshal invalid json should fail")
+    }
+    if _, err = FromRawMessage(json.RawMessage(`{"k" 1}`)); err == nil {
+        t.Fatal("build AST for invalid raw message should fail")
+    }
+}
@@ -0,0 +1,152 @@
+package json2ast
+
+import (
+    "strings"
+    "sync"
+)
+
+// parallelChunk is the least number of bytes of a chunk, smaller ones aren't worth a goroutine
+var parallelChunk = 256 << 10
+
+// arrayChunk is a run of elements of the top-level array: from the comma before its first
+// element (the first chunk from its first element) up to the comma after its last one
+// (the last chunk up to the closing bracket)
+type arrayChunk struct {
+    start Position
+    end   int // byte offset
+}
+
+type arraySplit struct {
+    open     Position // of the brackets of the array
+    close    Position
+    chunks   []arrayChunk
+    elements int
+}
+
+// parseParallel parses the chunks of a top-level array on opts.Parallel goroutines and puts
+// their elements together. ok is false when the source is not such an array or has an
+// error, it is then left to the sequential parser, so that the errors are the same
+func parseParallel(source string, opts Options) (JsonAst, bool) {
+    if opts.MaxTokens > 0 || opts.MaxBytes > 0 && len(source) > opts.MaxBytes {
+        return JsonAst{}, false // the tokens are counted over the whole source
+    }
+    var size = len(source) / (4 * opts.Parallel)
+    if size < parallelChunk { size = parallelChunk }
+    sp, ok := splitArray(source, size, opts.UTF16Columns)
+    if !ok || opts.MaxMembers > 0 && sp.elements > opts.MaxMembers {
+        return JsonAst{}, false
+    }
+
+    var elements = make([][]JsonAst, len(sp.chunks))
+    var parsed = make([]bool, len(sp.chunks))
+    var wg sync.WaitGroup
+    var slots = make(chan struct{}, opts.Parallel)
+    for i, c := range sp.chunks {
+        wg.Add(1)
+        slots <- struct{}{}
+        go func(i int, c arrayChunk) {
+            defer func() { <-slots; wg.Done() }()
+            elements[i], parsed[i] = parseChunk(source, opts, c, i == 0)
+        }(i, c)
+    }
+    wg.Wait()
+
+    var ast = JsonAst{
+        ArrayAst: make([]JsonAst, 0, sp.elements),
+        Typ: Array,
+        Loc: location{sp.open.Line, sp.open.Column},
+        End: location{sp.close.Line, sp.close.Column + 1},
+    }
+    for i := range elements {
+        if !parsed[i] { return JsonAst{}, false }
+        ast.ArrayAst = append(ast.ArrayAst, elements[i]...)
+    }
+    return ast, true
+}
+
+// parseChunk parses the elements of a chunk as if the array around them was open:
+// a chunk is ", E , E ..." which ES derives, the first one starts with an element
+func parseChunk(source string, opts Options, c arrayChunk, first bool) ([]JsonAst, bool) {
+    var g = jsonGrammar
+    var b = &astBuilder{open: []openNode{{ast: JsonAst{Typ: Array}}}}
+    var p = &parser{
+        scanner: newScannerAt(source[:c.end], opts, c.start),
+        limits: opts,
+        containers: []container{{typ: Array}},
+        closing: &jsonToken{Typ: RightBracket, Loc: location{-1, -1}},
+        build: b,
+    }
+    var start = []symbol{{terminal, int(endOfInput)}, {terminal, int(RightBracket)}, g.nonterminal("ES")}
+    if first {
+        start = append(start, symbol{semantic, int(elementAction)}, g.nonterminal("E"), symbol{semantic, int(itemAction)})
+    }
+    if errs := p.run(start...); len(errs) != 0 {
+        return nil, false
+    }
+    return b.open[0].ast.ArrayAst, true
+}
+
+// splitArray splits the top-level array of source into chunks of about size bytes with a
+// structural pre-scan, which looks only at strings, brackets, commas and newlines.
+// ok is false when there are less than two chunks or the source is not one array
+func splitArray(source string, size int, utf16 bool) (sp arraySplit, ok bool) {
+    var line, col, from = 1, 1, 0 // col is the column of the byte at from
+    var position = func(offset int) Position {
+        col += columns(source[from:offset], utf16)
+        from = offset
+        return Position{offset, line, col}
+    }
+
+    var i = 0
+    for ; i < len(source) && isSpace(rune(source[i])); i++ {
+        if source[i] == '\n' { line, col, from = line+1, 1, i+1 }
+    }
+    if i == len(source) || source[i] != '[' {
+        return sp, false
+    }
+    sp.open = position(i)
+    var chunk = arrayChunk{start: position(i + 1)}
+
+    for depth := 1; depth > 0; {
+        i++
+        if i >= len(source) { return sp, false }
+        switch source[i] {
+        case '{', '[':
+            depth++
+        case '}', ']':
+            depth--
+        case ',':
+            if depth != 1 { break }
+            sp.elements++
+            if i-chunk.start.Offset >= size {
+                chunk.end = i
+                sp.chunks = append(sp.chunks, chunk)
+                chunk = arrayChunk{start: position(i)}
+            }
+        case '\n':
+            line, col, from = line+1, 1, i+1
+        case '"':
+            for {
+                var j = strings.IndexByte(source[i+1:], '"')
+                if j < 0 { return sp, false }
+                i += j + 1
+                // the quote ends the string unless an odd number of backslashes escapes it
+                var n = 0
+                for source[i-1-n] == '\\' { n++ }
+                if n%2 == 0 { break }
+            }
+        }
+    }
+    if source[i] != ']' {
+        return sp, false
+    }
+    sp.close = position(i)
+    chunk.end = i
+    sp.chunks = append(sp.chunks, chunk)
+    sp.elements++
+
+    for i++; i < len(source); i++ {
+        if !isSpace(rune(source[i])) { return sp, false }
+    }
+    return sp, len(sp.chunks) > 1
+}
@@ -0,0 +1,103 @@
+package json2ast
+
+import (
+    "math/rand"
+    "reflect"
+    "strconv"
+    "strings"
+    "testing"
+)
+
+// the parallel parser gives the ast and the errors of the sequential one
+func TestParallelMatchesSequential(t *testing.T) {
+    defer func(n int) { parallelChunk = n }(parallelChunk)
+    parallelChunk = 1
+
+    var sources = append(astCorpus(),
+        "[1, 2, 3]",
+        " \n [\"😀\", {\"a\\\\\": [\n 1, \"b\\\"\"]},\r\n\t\"é\", [[]], {}] \n",
+        "[1, 2,]",
+        "[1, 2] 3",
+        "x [1, 2]",
+        `[1, "a, 2]`,
+        `[1, "a\n", 2]`,
+        "[1, \xff, 2]",
+        `[[1, 2], [3, 4], {"a": [5]}]`,
+        `[1, 2}`,
+        `[1, 2, 3`,
+    )
+    var r = rand.New(rand.NewSource(1))
+    var doc = `[{"a": [1, "x,y", {"b": null}]}, "é😀", -1.5e3, true, [[], {}], "\\"]`
+    for i := 0; i < 500; i++ { // with a byte changed, removed or inserted
+        var j = r.Intn(len(doc))
+        var c = string("[]{},:\"\\ \n1xé"[r.Intn(14)])
+        switch r.Intn(3) {
+        case 0:
+            sources = append(sources, doc[:j]+c+doc[j+1:])
+        case 1:
+            sources = append(sources, doc[:j]+doc[j+1:])
+        default:
+            sources = append(sources, doc[:j]+c+doc[j:])
+        }
+    }
+
+    var options = []Options{
+        {Parallel: 4},
+        {Parallel: 3, UTF16Columns: true, ReplaceInvalid: true},
+        {Parallel: 2, MaxDepth: 2, MaxMembers: 3, MaxStringLength: 4},
+    }
+    for _, source := range sources {
+        for _, opts := range options {
+            ast, errs := ParserWithOptions(source, opts)
+            opts.Parallel = 0
+            want, wantErrs := ParserWithOptions(source, opts)
+            if !reflect.DeepEqual(errs, wantErrs) || !reflect.DeepEqual(ast, want) {
+                t.Errorf("%q with %+v gives %+v %v, sequentially %+v %v", source, opts, ast, errs, want, wantErrs)
+            }
+            // a valid array is not left to the sequential parser
+            if _, split := splitArray(source, 1, false); split && len(wantErrs) == 0 {
+                opts.Parallel = 2
+                if _, ok := parseParallel(source, opts); !ok { t.Errorf("%q is parsed sequentially", source) }
+            }
+        }
+    }
+}
+
+func TestSplitArray(t *testing.T) {
+    var source = "\n [1, \"a,\\\"]\", \n {\"b\": [2, 3]}, 😀4] "
+    sp, ok := splitArray(source, 1, false)
+    if !ok || sp.elements != 4 || len(sp.chunks) != 4 {
+        t.Fatalf("%q is split into %+v, %v", source, sp, ok)
+    }
+    if sp.open != (Position{2, 2, 2}) || sp.close != (Position{37, 3, 19}) {
+        t.Errorf("brackets at %+v and %+v", sp.open, sp.close)
+    }
+    var starts = []Position{{3, 2, 3}, {4, 2, 4}, {13, 2, 13}, {30, 3, 15}}
+    for i, c := range sp.chunks {
+        if c.start != starts[i] || source[c.end] != ",]"[i/3] {
+            t.Errorf("chunk %d is %+v, want it to start at %+v", i, c, starts[i])
+        }
+    }
+
+    for _, source := range []string{"[1]", "{}", "[1, 2] x", `["1, 2]`, "[[1, 2]", "[1, 2}", " "} {
+        if sp, ok := splitArray(source, 1, false); ok {
+            t.Errorf("%q is split into %+v", source, sp)
+        }
+    }
+}
+
+func BenchmarkParserParallel(b *testing.B) {
+    var document = benchmarkDocument()
+    var elements = document[2:len(document)-3] // without the brackets
+    var source = "[\n" + strings.Repeat(elements+",\n", 7) + elements + "\n]\n"
+
+    for _, n := range []int{1, 2, 4, 8} {
+        b.Run(strconv.Itoa(n), func(b *testing.B) {
+            b.SetBytes(int64(len(source)))
+            b.ReportAllocs()
+            for i := 0; i < b.N; i++ {
+                ParserWithOptions(source, Options{Parallel: n})
+            }
+        })
+    }
+}
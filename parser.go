@@ -1,7 +1,7 @@
 package json2ast
 
 import (
-    "errors"
+    "io"
 )
 
 type AstType uint8
@@ -12,442 +12,357 @@ const (
     Literal
 )
 
-type nonTerminal uint8
-
-const (
-    parser nonTerminal = iota
-    element
-    array
-    elements
-    object
-    members
-)
+type literalAst jsonToken
 
-var firstSet = map[nonTerminal]map[tokenType]bool {
-    element: {LeftBrace: true, LeftBracket: true, String: true, Number: true, Boolean: true, Null: true},
-    array: {LeftBrace: true, LeftBracket: true, RightBracket: true ,String: true, Number: true, Boolean: true, Null: true},
-    elements: {RightBracket: true, Comma: true},
-    object: {RightBrace: true, String: true},
-    members: {RightBrace: true, Comma: true},
+// members of an object under construction
+type objectAst struct {
+    members map[string]JsonAst
+    keys    []string
 }
 
-type literalAst jsonToken
+// add adds a member, a duplicate key keeps its first position and takes the last value
+func (obj *objectAst) add(key string, value JsonAst) {
+    if _, ok := obj.members[key]; !ok { obj.keys = append(obj.keys, key) }
+    obj.members[key] = value
+}
 
 type JsonAst struct {
     ObjectAst   map[string]JsonAst
+    ObjectKeys  []string // keys of ObjectAst in document order
     ArrayAst    []JsonAst
     LiteralAst  literalAst
     Typ         AstType
+    Loc         location
+    End         location // just past the last character of the node
 }
 
-var cursor int
-var jts []jsonToken
-var jerrs []jsonError
-
-func Parser(source string) (JsonAst, []jsonError) {
-    jts, jerrs = tokenize(source)
-
-    if len(jerrs) != 0 || len(jts) == 0 {
-        return JsonAst{}, jerrs
-    }
-
-    cursor = 0
-    ast := parseElement(parser)
-    // expected end of json
-    if cursor < len(jts) {
-        jerrs = append(jerrs, jsonError{EndOfJsonExpected, jts[cursor].Loc})
-    }
-
-    if len(jerrs) != 0 {
-        ast = JsonAst{}
-    }
-
-    return ast, jerrs
+type container struct {
+    typ     AstType
+    members int // counted for MaxMembers, erroneous ones included
 }
 
-func getToken() (jsonToken, error) {
-    var token jsonToken
-    if cursor < len(jts) {
-        token = jts[cursor]
-        cursor++
-        return token, nil
-    }
-
-    return token, errors.New("overstep")
+// Options tune how the json text is read
+type Options struct {
+    // ReplaceInvalid replaces invalid utf-8 and the escapes of lone surrogates in strings
+    // with U+FFFD (\ufffd) instead of reporting InvalidUTF8 and LoneSurrogate
+    ReplaceInvalid bool
+
+    // limits for untrusted input, zero means no limit. parsing stops at the first
+    // limit exceeded, reported as TooLarge, TooManyTokens, StringTooLong, TooDeep or TooManyMembers
+    MaxBytes        int // size of the source in bytes
+    MaxTokens       int
+    MaxStringLength int // bytes between the quotes of a string, as written in the source
+    MaxDepth        int // nesting of objects and arrays
+    MaxMembers      int // members of an object or elements of an array
+
+    // UTF16Columns counts columns in UTF-16 code units, as javascript and LSP do, instead of runes
+    UTF16Columns bool
+
+    // Parallel parses the elements of a top-level array on up to Parallel goroutines (see
+    // parseParallel), ParserWithOptions gives the same ast and errors as without it
+    Parallel int
 }
 
-func goPanic(nT nonTerminal, syncTokenTypes ...tokenType) (bool, bool, jsonToken) {
-    var sync = false
-    var first = false
-    var token jsonToken
-    var err error
-    for token, err = getToken(); err == nil; token, err = getToken() {
-        if firstSet[nT][token.Typ] {
-            first = true
-            break
-        }
-        for _, stt := range syncTokenTypes {
-            if token.Typ == stt {
-                sync = true
-                break // break the closest for
-            }
-        }
-        if sync { break }
-    }
-
-    return sync, first, token
+// limitExceeded unwinds the parser when a limit of the Options is exceeded
+type limitExceeded struct {
+    jerr jsonError
 }
 
-func parseElement(caller nonTerminal) JsonAst {
-    token, err := getToken()
-    if err != nil {
-        jerrs = append(jerrs, jsonError{ValueExpected, location{-1, -1}})
-        return JsonAst{}
-    }
-
-    if firstSet[element][token.Typ] {
-        return doParseElement(token)
-    }
-
-    if caller == parser {
-        jerrs = append(jerrs, jsonError{ValueExpected, token.Loc})
-        _, first, token := goPanic(element)
-        if first { _ = doParseElement(token) }
-        return JsonAst{}
-    }
-
-    if caller == elements {
-        if token.Typ == RightBrace || token.Typ == Colon {
-            jerrs = append(jerrs, jsonError{ValueExpected, token.Loc})
-            sync, first, token := goPanic(element, RightBracket, Comma)
-            if sync { cursor-- } // cursor go back to the Comma or RightBracket
-            if first { _ = doParseElement(token) }
-            return JsonAst{}
-        }
+func Parser(source string) (JsonAst, []jsonError) {
+    return ParserWithOptions(source, Options{})
+}
 
-        cursor-- // cursor go back to the Comma or RightBracket
-        if token.Typ == Comma { // Comma
-            jerrs = append(jerrs, jsonError{ValueExpected, token.Loc})
-        } else { // RightBracket
-            jerrs = append(jerrs, jsonError{TrailingComma, jts[cursor-1].Loc}) // Trailing comma
-        }
-        return JsonAst{}
+// ParserWithOptions parses the json text as opts says
+func ParserWithOptions(source string, opts Options) (JsonAst, []jsonError) {
+    if opts.Parallel > 1 {
+        if ast, ok := parseParallel(source, opts); ok { return ast, nil }
     }
-
-    if caller == object || caller == members {
-        jerrs = append(jerrs, jsonError{ValueExpected, token.Loc})
-        if token.Typ == RightBracket || token.Typ == Colon {
-            sync, first, token := goPanic(element, RightBrace, Comma)
-            if sync { cursor-- } // cursor go back to the Comma or RightBrace
-            if first { _ = doParseElement(token) }
-            return JsonAst{}
-        }
-
-        cursor-- // cursor go back to the Comma or RightBrace
-        return JsonAst{}
+    var b = &astBuilder{}
+    if errs := parseWith(source, opts, b); len(errs) != 0 {
+        return JsonAst{}, errs
     }
-
-    return JsonAst{}
+    return b.values[0], nil
 }
 
-func doParseElement(token jsonToken) JsonAst {
-    var ast JsonAst
-
-    if token.Typ == LeftBrace {
-        ast.ObjectAst = parseObject()
-        ast.Typ = Object
-    } else if token.Typ == LeftBracket {
-        ast.ArrayAst = parseArray()
-        ast.Typ = Array
-    } else {
-        ast.LiteralAst = literalAst(token)
-        ast.Typ = Literal
-    }
-
-    return ast
+// parser is the state of one parse, parsers don't share anything
+type parser struct {
+    scanner    *Scanner
+    lookahead  jsonToken   // the next token, of type endOfInput past the last one
+    previous   jsonToken   // the token before lookahead
+    missing    bool        // a terminal was taken as missing after previous, see recover
+    lexErrs    []jsonError // errors of the lexer, reported instead of the syntax errors
+    jerrs      []jsonError
+    limits     Options
+    containers []container // objects and arrays being parsed, innermost last
+    closing    *jsonToken  // taken as the last token of the source, if not nil
+    build      builder     // runs the semantic actions
+    tolerant   bool        // run the actions after an error too
+    report     func(jerr jsonError) // called with every error as it is found, if not nil
 }
 
-func parseObject() map[string]JsonAst {
-    token, err := getToken()
-    if err != nil {
-        jerrs = append(jerrs, jsonError{PropertyOrClosingBraceExpected, location{-1, -1}})
-        return nil
-    }
-
-    if token.Typ == RightBrace {
-        return map[string]JsonAst{}
-    }
-    if token.Typ == String {
-        var objAst = doParseMember(token)
-        if isNextRightBrace() { return objAst }
-        return nil
-    }
+// parseWith parses the json text, b runs the semantic actions while there is no error.
+// the tokens are read as the parser goes, they are not kept
+func parseWith(source string, opts Options, b builder) []jsonError {
+    var p = &parser{scanner: NewScannerWithOptions(source, opts), limits: opts, build: b}
+    return p.run(symbol{nonterminal, 0})
+}
 
-    jerrs = append(jerrs, jsonError{PropertyOrClosingBraceExpected, token.Loc})
-    cursor-- // for this token may also be sync tokens Comma or Colon
-    sync, first, token := goPanic(object, Comma, Colon)
-    if first {
-        if token.Typ == String {
-            _ = doParseMember(token)
-            _ = isNextRightBrace()
-        } // else RightBrace
-        return nil
-    }
-    if sync {
-        if token.Typ == Comma {
-            cursor-- // cursor go back to the Comma
-        } else { // Colon
-            parseElement(object)
+// run parses the tokens of the scanner with start on the stack, its top last
+func (p *parser) run(start ...symbol) (errs []jsonError) {
+    defer func() {
+        if r := recover(); r != nil {
+            exceeded, ok := r.(limitExceeded)
+            if !ok { panic(r) }
+            p.notify(exceeded.jerr)
+            // the rest of the input is not read, the limit comes after the errors found so far
+            errs = p.jerrs
+            if len(p.lexErrs) != 0 { errs = p.lexErrs }
+            errs = append(errs, exceeded.jerr)
         }
-        _ = parseObjMembers()
-        _ = isNextRightBrace()
-        return nil
-    }
+    }()
 
-    return nil
+    p.advance()
+    p.parse(start)
+    return p.finish(p.jerrs)
 }
 
-func parseObjMembers() map[string]JsonAst {
-    token, err := getToken()
-    if err != nil {
-        jerrs = append(jerrs, jsonError{CommaOrClosingBraceExpected, location{-1, -1}})
-        return nil
+// finish reads the rest of the tokens, if the lexer finds errors they are reported instead of errs
+func (p *parser) finish(errs []jsonError) []jsonError {
+    for p.lookahead.Typ != endOfInput {
+        p.advance()
     }
-
-    if firstSet[members][token.Typ] {
-        return doParseObjMembers(token)
+    if len(p.lexErrs) != 0 {
+        return p.lexErrs
     }
-
-    if token.Typ == String {
-        jerrs = append(jerrs, jsonError{CommaExpected, token.Loc})
-        _ = doParseMember(token)
-        return nil
-    }
-
-    jerrs = append(jerrs, jsonError{CommaOrClosingBraceExpected, token.Loc})
-    sync, first, token := goPanic(members, String)
-    if first {
-        _ = doParseObjMembers(token)
-        return nil
-    }
-    if sync {
-        _ = doParseMember(token)
-        return nil
-    }
-
-    return nil
+    return errs
 }
 
-func doParseObjMembers(token jsonToken) map[string]JsonAst {
-    if token.Typ == RightBrace {
-        cursor-- // ɛ
-        return map[string]JsonAst{}
-    }
-
-    if token.Typ == Comma {
-        token, err := getToken()
-        if err != nil {
-            jerrs = append(jerrs, jsonError{PropertyExpected, location{-1, -1}})
-            return nil
+// advance reads the next token into lookahead, skipping the invalid ones
+func (p *parser) advance() {
+    p.previous, p.missing = p.lookahead, false
+    for {
+        token, err := p.scanner.Next()
+        if err == io.EOF && p.closing != nil {
+            p.lookahead, p.closing = *p.closing, nil
+            return
         }
-
-        if token.Typ == String {
-            return doParseMember(token)
+        if err == io.EOF {
+            p.lookahead = jsonToken{Typ: endOfInput, Loc: location{-1, -1}}
+            return
         }
-
-        if token.Typ == Colon {
-            cursor-- // pretend to insert a String
-            jerrs = append(jerrs, jsonError{PropertyExpected, token.Loc})
-            _ = doParseMember(jsonToken{Val: "dummy"})
-            return nil
-        }
-
-        if token.Typ == Comma {
-            jerrs = append(jerrs, jsonError{PropertyExpected, token.Loc})
-            cursor-- // cursor go back to the Comma
-            _ = parseObjMembers()
-            return nil
-        }
-
-        if token.Typ == RightBrace {
-            cursor-- // cursor go back to the RightBrace
-            jerrs = append(jerrs, jsonError{TrailingComma, jts[cursor-1].Loc}) // Trailing comma
-            return nil
+        if err != nil {
+            p.lexErrs = append(p.lexErrs, err.(jsonError))
+            p.notify(err.(jsonError))
+            continue
         }
+        p.lookahead = token.jsonToken()
+        return
+    }
+}
 
-        // other cases
-        jerrs = append(jerrs, jsonError{PropertyExpected, token.Loc})
-        sync, first, token := goPanic(members, String, Colon)
-        if first {
-            cursor-- // cursor go back to the Comma or RightBrace
-            _ = parseObjMembers()
-            return nil
-        }
-        if sync {
-            if token.Typ == String {
-                _ = doParseMember(token)
-                return nil
-            } else { // Colon
-                cursor-- // pretend to insert a string key
-                _ = doParseMember(jsonToken{Val: "dummy"})
-                return nil
+// parse parses the tokens from lookahead on with the predictive table of jsonGrammar.
+// on an error the parser recovers in panic mode on the FOLLOW sets (see recover), no other error
+// is reported until a token is matched
+func (p *parser) parse(start []symbol) {
+    var g = jsonGrammar
+    var recovering = false
+    var stack = append([]symbol{}, start...) // of the predictive parser
+
+    for len(stack) != 0 {
+        var top = stack[len(stack)-1]
+        var la = p.lookahead.Typ
+        switch top.kind {
+        case semantic:
+            stack = stack[:len(stack)-1]
+            p.act(action(top.id))
+            continue
+        case terminal:
+            if TokenType(top.id) == la {
+                stack = stack[:len(stack)-1]
+                if la != endOfInput { p.advance() }
+                recovering = false
+                continue
+            }
+        case nonterminal:
+            if i := g.table[top.id][la]; i != -1 {
+                stack = expand(stack, g.productions[i])
+                continue
             }
         }
-        return nil
+        if !recovering { p.syntaxError(top, stack); recovering = true }
+        stack = p.recover(stack)
     }
-
-    return nil
 }
 
-func doParseMember(token jsonToken) map[string]JsonAst {
-    var objAst = make(map[string]JsonAst)
-    var key = token.Val
-
-    token, err := getToken()
-    if err != nil {
-        jerrs = append(jerrs, jsonError{ColonExpected, location{-1, -1}})
-        return nil
-    }
-
-    if token.Typ == Colon {
-        objAst[key] = parseElement(object)
-        others := parseObjMembers()
-        for k, v := range others {
-            objAst[k] = v
+// recover is the panic mode after an error at the top of the stack: the tokens are skipped
+// until one in the FIRST set of the top, to expand it, or one in its FOLLOW set, to pop it.
+// a terminal on the top has no FIRST set but itself, and all of FOLLOW. a token in FOLLOW only
+// syncs when a symbol under the top matches it, down to the closer of the innermost container,
+// so a token never closes a container outside the one being recovered.
+// at the end of the input the top is popped
+func (p *parser) recover(stack []symbol) []symbol {
+    var g = jsonGrammar
+    var top = len(stack) - 1
+    var sym = stack[top]
+    var low = g.scope(stack)
+    for skipped := false; ; skipped = true {
+        var la = p.lookahead.Typ
+        if la == endOfInput {
+            return p.popTo(stack, top-1)
+        }
+        var follows = sym.kind == terminal || g.follow[sym.id][la]
+        if g.matches(sym, la) || follows && g.resync(stack[:top], low, la) != -1 {
+            if skipped { p.trailing(p.previous, stack) }
+            if g.matches(sym, la) { return stack }
+            return p.popTo(stack, top-1)
         }
-        return objAst
+        p.advance()
     }
+}
 
-    jerrs = append(jerrs, jsonError{ColonExpected, token.Loc})
-
-    if token.Typ == Comma {
-        cursor-- // cursor go back to the Comma
-        _ = parseObjMembers()
-        return nil
+// popTo pops the symbols above stack[i], running the semantic actions among them.
+// the terminals popped are taken as missing
+func (p *parser) popTo(stack []symbol, i int) []symbol {
+    for len(stack) > i+1 {
+        var sym = stack[len(stack)-1]
+        stack = stack[:len(stack)-1]
+        switch sym.kind {
+        case terminal:
+            p.missing = true
+        case semantic:
+            p.act(action(sym.id))
+        }
     }
+    return stack
+}
 
-    if token.Typ != Colon {
-        cursor-- // pretend to insert a Colon
-        parseElement(object)
-        _ = parseObjMembers()
-        return nil
+// expand replaces the nonterminal on the top of the stack by the body of p
+func expand(stack []symbol, p production) []symbol {
+    stack = stack[:len(stack)-1]
+    for i := len(p.body) - 1; i >= 0; i-- {
+        stack = append(stack, p.body[i])
     }
+    return stack
+}
 
-    return nil
+// syntaxError reports that sym, the top of the stack, is not matched by lookahead
+func (p *parser) syntaxError(sym symbol, stack []symbol) {
+    var g = jsonGrammar
+    var typ, loc = g.expected[sym], p.lookahead.Loc
+    if on, ok := jsonExpectedOn[sym][p.lookahead.Typ]; ok { typ = on }
+    if p.trailing(p.previous, stack) { return }
+    p.jerrs = append(p.jerrs, jsonError{typ, loc})
+    p.notify(jsonError{typ, loc})
 }
 
-func isNextRightBrace() bool {
-    token, err := getToken()
-    if err != nil || token.Typ != RightBrace {
-        if err != nil {
-            jerrs = append(jerrs, jsonError{CommaOrClosingBraceExpected, location{-1, -1}})
-        } else {
-            jerrs = append(jerrs, jsonError{CommaOrClosingBraceExpected, token.Loc})
-        }
+// trailing reports token, a separator which the closer of the innermost container follows
+func (p *parser) trailing(token jsonToken, stack []symbol) bool {
+    var g = jsonGrammar
+    var typ, ok = jsonTrailing[token.Typ]
+    if !ok || p.lookahead.Typ == endOfInput || int(p.lookahead.Typ) != g.closerOf(stack[g.scope(stack)]) {
         return false
     }
+    p.jerrs = append(p.jerrs, jsonError{typ, token.Loc})
+    p.notify(jsonError{typ, token.Loc})
     return true
 }
 
-func parseArray() []JsonAst {
-    token, err := getToken()
-    if err != nil {
-        jerrs = append(jerrs, jsonError{CommaOrClosingBracketExpected, location{-1, -1}})
-        return nil
-    }
-
-    if firstSet[array][token.Typ] {
-        return doParseArray(token)
-    }
-
-    jerrs = append(jerrs, jsonError{ValueExpected, token.Loc})
-    cursor-- // for this token may also be sync token Comma
-    sync, first, token := goPanic(array, Comma)
-    if first {
-        _ = doParseArray(token)
-        return nil
-    }
-    if sync {
-        cursor-- // cursor go back to the Comma
-        _ = parseAryElements()
-        _ = isNextRightBracket()
-        return nil
-    }
-
-    return nil
+func (p *parser) notify(jerr jsonError) {
+    if p.report != nil { p.report(jerr) }
 }
 
-func doParseArray(token jsonToken) []JsonAst {
-    var arrayAst = make([]JsonAst, 0)
+// act runs a semantic action, previous is the token just matched. after a token taken as
+// missing the token is an empty one at lookahead, and no key or literal is built for it.
+// after an error the containers are still entered and left for the limits, but no node is built
+// unless the parser is tolerant
+func (p *parser) act(a action) {
+    var token = p.previous
+    if p.missing { token = jsonToken{Loc: p.lookahead.Loc, End: p.lookahead.Loc} }
+    switch a {
+    case objectAction:
+        p.enterContainer(Object, token.Loc)
+    case arrayAction:
+        p.enterContainer(Array, token.Loc)
+    case keyAction:
+        p.countMember(Object, token.Loc)
+    case itemAction:
+        p.countMember(Array, p.lookahead.Loc)
+    }
+    var made = p.missing && (a == literalAction || a == keyAction)
+    if !made && (p.tolerant || len(p.jerrs) == 0 && len(p.lexErrs) == 0) { p.build.act(a, token) }
+    if a == endAction { p.leaveContainer() }
+}
 
-    if token.Typ == RightBracket {
-        return arrayAst
-    }
+// builder builds the nodes recognized by the semantic actions
+type builder interface {
+    act(a action, token jsonToken)
+}
 
-    cursor-- // cursor back to the "element"
-    arrayAst = append(arrayAst, parseElement(array))
-    arrayAst = append(arrayAst, parseAryElements()...)
+// astBuilder builds a JsonAst
+type astBuilder struct {
+    values []JsonAst  // nodes built, waiting for their container
+    open   []openNode // objects and arrays being built, innermost last
+}
 
-    if isNextRightBracket() {
-        return arrayAst
-    }
-    return nil
+type openNode struct {
+    ast    JsonAst
+    object objectAst // members parsed so far
+    key    string    // of the member being parsed
 }
 
-func isNextRightBracket() bool {
-    token, err := getToken()
-    if err != nil || token.Typ != RightBracket {
-        if err != nil {
-            jerrs = append(jerrs, jsonError{CommaOrClosingBracketExpected, location{-1, -1}})
-        } else {
-            jerrs = append(jerrs, jsonError{CommaOrClosingBracketExpected, token.Loc})
+func (b *astBuilder) act(a action, token jsonToken) {
+    switch a {
+    case objectAction:
+        b.open = append(b.open, openNode{ast: JsonAst{Typ: Object, Loc: token.Loc}, object: objectAst{members: map[string]JsonAst{}}})
+    case arrayAction:
+        b.open = append(b.open, openNode{ast: JsonAst{ArrayAst: make([]JsonAst, 0), Typ: Array, Loc: token.Loc}})
+    case endAction:
+        var n = b.open[len(b.open)-1]
+        b.open = b.open[:len(b.open)-1]
+        if n.ast.Typ == Object {
+            n.ast.ObjectAst, n.ast.ObjectKeys = n.object.members, n.object.keys
         }
-        return false
+        n.ast.End = token.End
+        b.values = append(b.values, n.ast)
+    case literalAction:
+        b.values = append(b.values, JsonAst{LiteralAst: literalAst(token), Typ: Literal, Loc: token.Loc, End: token.End})
+    case keyAction:
+        b.open[len(b.open)-1].key = token.Val
+    case memberAction:
+        var n = &b.open[len(b.open)-1]
+        n.object.add(n.key, b.pop())
+    case elementAction:
+        var n = &b.open[len(b.open)-1]
+        n.ast.ArrayAst = append(n.ast.ArrayAst, b.pop())
     }
-    return true
 }
 
-func parseAryElements() []JsonAst {
-    token, err := getToken()
-    if err != nil {
-        jerrs = append(jerrs, jsonError{CommaOrClosingBracketExpected, location{-1, -1}})
-        return nil
-    }
-
-    if token.Typ == RightBracket {
-        cursor-- // ɛ
-        return nil
-    }
+func (b *astBuilder) pop() JsonAst {
+    var v = b.values[len(b.values)-1]
+    b.values = b.values[:len(b.values)-1]
+    return v
+}
 
-    if token.Typ == Comma {
-        var arrayAst = make([]JsonAst, 0)
-        arrayAst = append(arrayAst, parseElement(elements))
-        arrayAst = append(arrayAst, parseAryElements()...)
-        return arrayAst
+// countMember counts a member of the innermost container when it is of type typ
+func (p *parser) countMember(typ AstType, loc location) {
+    if len(p.containers) == 0 || p.containers[len(p.containers)-1].typ != typ {
+        return
     }
-
-    jerrs = append(jerrs, jsonError{CommaOrClosingBracketExpected, token.Loc})
-
-    if firstSet[element][token.Typ] {
-        cursor-- // cursor back to the "element"
-        _ = parseElement(elements)
-        _ = parseAryElements()
-        return nil
+    var c = &p.containers[len(p.containers)-1]
+    c.members++
+    if p.limits.MaxMembers > 0 && c.members > p.limits.MaxMembers {
+        panic(limitExceeded{jsonError{TooManyMembers, loc}})
     }
+}
 
-    if token.Typ == RightBrace || token.Typ == Colon {
-        _, first, token := goPanic(elements)
-        if first {
-            if token.Typ == RightBracket {
-                cursor-- // cursor back to the RightBracket
-                return nil
-            } else { // Comma
-                _ = parseElement(elements)
-                _ = parseAryElements()
-                return nil
-            }
-        }
+// enterContainer is called at the opening brace or bracket, leaveContainer at the end of the container
+func (p *parser) enterContainer(typ AstType, loc location) {
+    p.containers = append(p.containers, container{typ: typ})
+    if p.limits.MaxDepth > 0 && len(p.containers) > p.limits.MaxDepth {
+        panic(limitExceeded{jsonError{TooDeep, loc}})
     }
+}
 
-    return nil
+func (p *parser) leaveContainer() {
+    p.containers = p.containers[:len(p.containers)-1]
 }
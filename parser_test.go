@@ -3,12 +3,13 @@ package json2ast
 import (
     "encoding/json"
     "reflect"
+    "runtime/debug"
     "strings"
     "testing"
 )
 
-func TestParser(t *testing.T) {
-    validTests := []string {
+// valid json texts shared by the tests of the package
+var parserValidTests = []string {
     `
     {
         "bool1": true,
@@ -84,8 +85,10 @@ func TestParser(t *testing.T) {
     `false`,
     `"string"`,
     `[123, null, true, false, "string", {"type": "home", "number": "212 555-1234"}]`,
-    }
-    for _, vt := range validTests {
+}
+
+func TestParser(t *testing.T) {
+    for _, vt := range parserValidTests {
         if !json.Valid([]byte(vt)) {
             t.Fatalf("valid test `%s` is invalid", vt)
         }
@@ -124,31 +127,89 @@ func TestParser(t *testing.T) {
         t.Fatalf("unsupported AST type: %d", ast.Typ)
     }
 
-    invalidTests := []string {
-        `{{[], "k1":{[]{:123}, "k2":{][{, "k3":123}, "k4":{1 true null false: 123}, "k5":{, "k6":123}, "k7":{:123}}`,
-        `{"k1":123 "k2":123, "k3":123, "k4":{"k5":123 {][ 123 true false null:, "k6": 123 }}`,
-        `{"k1":[}:}:, 123], "k2":[:}:}, 123], "k3":[,123,123], "k4":[}:"v1"], "k5":[:}123], "k6":[:}true], "k7":[:}[]]}`,
-        `{"k1":[}}{"k2":123}], "k3":[}}{"k4":123}], "k5":[}}null, 123]}`,
-        `{"k1":[123}:}:], "k2":[123}:}:, 123], "k3":[123:}:}{"k4":123}, 123], "k5":[123}:}:[123,123]], "k6":[123 123]}`,
-        `{"k1":[123 "v1"], "k2":[123 true], "k3":[123 false], "k4":[123 null]}`,
-        `{"k1":[123,], "k2":[123,}:}::,123], "k3":[123,:}:{}], "k4":[123,:}:[123]], "k5":[123,:}:"v1"], "k6":[123,:}:123]}`,
-        `{"k1":[123,}:false], "k2":[123,}:true], "k3":[123,}:null]}, "k4":[123,,456]}`,
-        `{"k1":{"k2"::]:]}, "k3":}}`,
-        `{"k1":]:], "k2":123, "k3":{"k4":,}}`,
-        `,:}]{"k1":123}{},:[] "k1" 123 null false true`,
+    invalidTests := []struct {
+        source string
+        want   []jsonError
+    }{
+        {`{{[], "k1":{[]{:123}, "k2":{][{, "k3":123}, "k4":{1 true null false: 123}, "k5":{, "k6":123}, "k7":{:123}}`, []jsonError{
+            {PropertyOrClosingBraceExpected, location{1, 2}},
+            {PropertyOrClosingBraceExpected, location{1, 13}},
+            {PropertyOrClosingBraceExpected, location{1, 29}},
+            {PropertyOrClosingBraceExpected, location{1, 51}},
+            {PropertyOrClosingBraceExpected, location{1, 82}},
+            {PropertyOrClosingBraceExpected, location{1, 101}},
+        }},
+        {`{"k1":123 "k2":123, "k3":123, "k4":{"k5":123 {][ 123 true false null:, "k6": 123 }}`, []jsonError{
+            {CommaExpected, location{1, 11}},
+            {CommaOrClosingBraceExpected, location{1, 46}},
+        }},
+        {`{"k1":[}:}:, 123], "k2":[:}:}, 123], "k3":[,123,123], "k4":[}:"v1"], "k5":[:}123], "k6":[:}true], "k7":[:}[]]}`, []jsonError{
+            {ValueExpected, location{1, 8}},
+            {ValueExpected, location{1, 26}},
+            {ValueExpected, location{1, 44}},
+            {ValueExpected, location{1, 61}},
+            {ValueExpected, location{1, 76}},
+            {ValueExpected, location{1, 90}},
+            {ValueExpected, location{1, 105}},
+        }},
+        {`{"k1":[}}{"k2":123}], "k3":[}}{"k4":123}], "k5":[}}null, 123]}`, []jsonError{
+            {ValueExpected, location{1, 8}},
+            {ValueExpected, location{1, 29}},
+            {ValueExpected, location{1, 50}},
+        }},
+        {`{"k1":[123}:}:], "k2":[123}:}:, 123], "k3":[123:}:}{"k4":123}, 123], "k5":[123}:}:[123,123]], "k6":[123 123]}`, []jsonError{
+            {CommaOrClosingBracketExpected, location{1, 11}},
+            {CommaOrClosingBracketExpected, location{1, 27}},
+            {CommaOrClosingBracketExpected, location{1, 48}},
+            {CommaOrClosingBracketExpected, location{1, 79}},
+            {CommaOrClosingBraceExpected, location{1, 92}},
+            {CommaOrClosingBracketExpected, location{1, 105}},
+        }},
+        {`{"k1":[123 "v1"], "k2":[123 true], "k3":[123 false], "k4":[123 null]}`, []jsonError{
+            {CommaOrClosingBracketExpected, location{1, 12}},
+            {CommaOrClosingBracketExpected, location{1, 29}},
+            {CommaOrClosingBracketExpected, location{1, 46}},
+            {CommaOrClosingBracketExpected, location{1, 64}},
+        }},
+        {`{"k1":[123,], "k2":[123,}:}::,123], "k3":[123,:}:{}], "k4":[123,:}:[123]], "k5":[123,:}:"v1"], "k6":[123,:}:123]}`, []jsonError{
+            {TrailingComma, location{1, 11}},
+            {ValueExpected, location{1, 25}},
+            {ValueExpected, location{1, 47}},
+            {ValueExpected, location{1, 65}},
+            {ValueExpected, location{1, 86}},
+            {ValueExpected, location{1, 106}},
+        }},
+        {`{"k1":[123,}:false], "k2":[123,}:true], "k3":[123,}:null]}, "k4":[123,,456]}`, []jsonError{
+            {ValueExpected, location{1, 12}},
+            {ValueExpected, location{1, 32}},
+            {ValueExpected, location{1, 51}},
+            {EndOfJsonExpected, location{1, 59}},
+        }},
+        {`{"k1":{"k2"::]:]}, "k3":}}`, []jsonError{
+            {ValueExpected, location{1, 13}},
+            {ValueExpected, location{1, 25}},
+            {EndOfJsonExpected, location{1, 26}},
+        }},
+        {`{"k1":]:], "k2":123, "k3":{"k4":,}}`, []jsonError{
+            {ValueExpected, location{1, 7}},
+            {ValueExpected, location{1, 33}},
+            {TrailingComma, location{1, 33}},
+        }},
+        {`,:}]{"k1":123}{},:[] "k1" 123 null false true`, []jsonError{
+            {ValueExpected, location{1, 1}},
+            {EndOfJsonExpected, location{1, 15}},
+        }},
     }
 
     for _, ivt := range invalidTests {
-        if json.Valid([]byte(ivt)) {
-            t.Fatalf("invalid test `%s` is valid", ivt)
+        if json.Valid([]byte(ivt.source)) {
+            t.Fatalf("invalid test `%s` is valid", ivt.source)
         }
 
-        _, jerrs := Parser(ivt)
-        t.Logf("errors of `%s` :", ivt)
-        for _, jerr := range jerrs {
-            t.Log(jerr)
+        _, jerrs := Parser(ivt.source)
+        if !reflect.DeepEqual(jerrs, ivt.want) {
+            t.Errorf("errors of `%s` are %v, want %v", ivt.source, jerrs, ivt.want)
         }
-        t.Log()
     }
 }
 
@@ -195,3 +256,103 @@ func ast2JsonText(ast JsonAst) string {
 
     return sb.String()
 }
+
+func TestParserObjectKeys(t *testing.T) {
+    ast, jerrs := Parser(`{"b": 1, "a": {"z": 1, "y": 2}, "c": 3, "a": null}`)
+    if len(jerrs) != 0 {
+        t.Fatal("build AST failed")
+    }
+    if !reflect.DeepEqual(ast.ObjectKeys, []string{`"b"`, `"a"`, `"c"`}) {
+        t.Fatalf("object keys are %v", ast.ObjectKeys)
+    }
+    if ast.ObjectAst[`"a"`].Typ != Literal {
+        t.Fatalf("the last duplicate member should win")
+    }
+
+    b, _ := ast.MarshalJSON()
+    if string(b) != `{"b":1,"a":null,"c":3}` {
+        t.Fatalf("json text is `%s`", b)
+    }
+}
+
+func TestParserLimits(t *testing.T) {
+    tests := []struct {
+        source string
+        opts   Options
+        want   jsonError
+        atOpts Options // right at the limit
+    }{
+        {"[1, 2,\n 3]", Options{MaxBytes: 8}, jsonError{TooLarge, location{2, 2}}, Options{MaxBytes: 10}},
+        {"[1, 2, 3]", Options{MaxTokens: 6}, jsonError{TooManyTokens, location{1, 9}}, Options{MaxTokens: 7}},
+        {`{"a": "abcd", "b": "abcde"}`, Options{MaxStringLength: 4}, jsonError{StringTooLong, location{1, 20}}, Options{MaxStringLength: 5}},
+        {`[[1], [[2]], [[[3]]]]`, Options{MaxDepth: 3}, jsonError{TooDeep, location{1, 16}}, Options{MaxDepth: 4}},
+        {`{"a": [1, 2], "b": 3, "c": 4}`, Options{MaxMembers: 2}, jsonError{TooManyMembers, location{1, 23}}, Options{MaxMembers: 3}},
+        {`[{"a": 1, "b": 2}, 3, [4]]`, Options{MaxMembers: 2}, jsonError{TooManyMembers, location{1, 23}}, Options{MaxMembers: 3}},
+    }
+
+    for _, tt := range tests {
+        ast, jerrs := ParserWithOptions(tt.source, tt.opts)
+        if len(jerrs) != 1 || jerrs[0] != tt.want || ast.Typ != Object || ast.ObjectAst != nil {
+            t.Errorf("`%s` with %+v gives errors %v, want %v", tt.source, tt.opts, jerrs, tt.want)
+        }
+        if _, jerrs := ParserWithOptions(tt.source, tt.atOpts); len(jerrs) != 0 {
+            t.Errorf("`%s` with %+v gives errors %v", tt.source, tt.atOpts, jerrs)
+        }
+    }
+
+    // the depth is bounded before the recursion grows
+    var deep = strings.Repeat("[", 1000000)
+    if _, jerrs := ParserWithOptions(deep, Options{MaxDepth: 100}); len(jerrs) != 1 || jerrs[0].typ != TooDeep {
+        t.Errorf("deep nesting gives errors %v", jerrs)
+    }
+
+    // errors found before the limit are kept
+    if _, jerrs := ParserWithOptions(`[1 2, [[3]]]`, Options{MaxDepth: 2}); len(jerrs) != 2 || jerrs[1].typ != TooDeep {
+        t.Errorf("got errors %v", jerrs)
+    }
+    if _, jerrs := ParserWithOptions(`["\q", [[1]]]`, Options{MaxDepth: 2}); len(jerrs) != 2 || jerrs[0].typ != InvalidEscape || jerrs[1].typ != TooDeep {
+        t.Errorf("got errors %v", jerrs)
+    }
+
+    // the input past the limit is not read, its errors are not reported
+    var past = []struct {
+        source string
+        opts   Options
+        want   jsonError
+    }{
+        {`[[[1]], "\q"]`, Options{MaxDepth: 2}, jsonError{TooDeep, location{1, 3}}},
+        {`[1, 2, 3, "\q", 1e]`, Options{MaxMembers: 2}, jsonError{TooManyMembers, location{1, 8}}},
+        {`{"a": 1, "b": 2, "c": tru}`, Options{MaxMembers: 2}, jsonError{TooManyMembers, location{1, 18}}},
+    }
+    for _, tt := range past {
+        if _, jerrs := ParserWithOptions(tt.source, tt.opts); len(jerrs) != 1 || jerrs[0] != tt.want {
+            t.Errorf("`%s` with %+v gives errors %v, want %v", tt.source, tt.opts, jerrs, tt.want)
+        }
+    }
+}
+
+func TestParserDeepNesting(t *testing.T) {
+    // the parser must not recurse: a 100k deep document fits in a small goroutine stack
+    var old = debug.SetMaxStack(1 << 20)
+    defer debug.SetMaxStack(old)
+
+    const depth = 100000
+    ast, jerrs := Parser(strings.Repeat(`{"a":[`, depth) + "null" + strings.Repeat("]}", depth))
+    if len(jerrs) != 0 {
+        t.Fatalf("got errors %v", jerrs)
+    }
+    for i := 0; i < depth; i++ {
+        if ast.Typ != Object || len(ast.ObjectAst[`"a"`].ArrayAst) != 1 {
+            t.Fatalf("unexpected node at depth %d", i)
+        }
+        ast = ast.ObjectAst[`"a"`].ArrayAst[0]
+    }
+    if ast.Typ != Literal || ast.LiteralAst.Typ != Null {
+        t.Fatalf("innermost node is %+v", ast)
+    }
+
+    _, jerrs = Parser(strings.Repeat("[", depth) + strings.Repeat("]", depth-1))
+    if len(jerrs) == 0 || jerrs[0] != (jsonError{CommaOrClosingBracketExpected, location{-1, -1}}) {
+        t.Fatalf("got errors %v", jerrs)
+    }
+}
@@ -0,0 +1,90 @@
+package json2ast
+
+import (
+    "errors"
+    "strconv"
+    "strings"
+)
+
+// Lookup finds the node referenced by the json pointer (RFC 6901), e.g. "/servers/0/name"
+func Lookup(ast JsonAst, pointer string) (JsonAst, error) {
+    tokens, err := parsePointer(pointer)
+    if err != nil {
+        return JsonAst{}, err
+    }
+    node, ok := lookupPointer(ast, tokens)
+    if !ok {
+        return JsonAst{}, errors.New("no node at json pointer " + pointer)
+    }
+    return node, nil
+}
+
+// parsePointer splits a json pointer (RFC 6901) into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+    if pointer == "" {
+        return nil, nil
+    }
+    if pointer[0] != '/' {
+        return nil, errors.New("json pointer must start with '/': " + pointer)
+    }
+
+    var tokens = strings.Split(pointer[1:], "/")
+    for i, token := range tokens {
+        tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+    }
+    return tokens, nil
+}
+
+// member finds the value of the object member with the (unescaped) name
+func member(ast JsonAst, name string) (JsonAst, bool) {
+    if ast.Typ != Object {
+        return JsonAst{}, false
+    }
+    if v, ok := ast.ObjectAst[quote(name)]; ok {
+        return v, true
+    }
+    // the raw name may be written with other escapes
+    for k, v := range ast.ObjectAst {
+        if s, err := unquote(k); err == nil && s == name {
+            return v, true
+        }
+    }
+    return JsonAst{}, false
+}
+
+// lookupPointer walks the reference tokens down from ast
+func lookupPointer(ast JsonAst, tokens []string) (JsonAst, bool) {
+    for _, token := range tokens {
+        switch ast.Typ {
+        case Object:
+            v, ok := member(ast, token)
+            if !ok {
+                return JsonAst{}, false
+            }
+            ast = v
+        case Array:
+            i, ok := arrayIndex(token)
+            if !ok || i >= len(ast.ArrayAst) {
+                return JsonAst{}, false
+            }
+            ast = ast.ArrayAst[i]
+        default:
+            return JsonAst{}, false
+        }
+    }
+    return ast, true
+}
+
+// arrayIndex parses a reference token made of decimal digits without leading zeros
+func arrayIndex(token string) (int, bool) {
+    if token == "" || len(token) > 1 && token[0] == '0' {
+        return 0, false
+    }
+    for i := 0; i < len(token); i++ {
+        if token[i] < '0' || token[i] > '9' {
+            return 0, false
+        }
+    }
+    i, err := strconv.Atoi(token)
+    return i, err == nil
+}
@@ -0,0 +1,669 @@
+package json2ast
+
+import (
+    "fmt"
+    "math/big"
+    "net/url"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+    "unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema (draft 2020-12). the supported keywords are
+// type, enum, const, properties, patternProperties, additionalProperties, required,
+// minProperties, maxProperties, items, prefixItems, minItems, maxItems, uniqueItems,
+// minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// allOf, anyOf, oneOf, not and $ref to locations inside the schema document.
+// other keywords are ignored, pattern uses the RE2 syntax of package regexp
+type Schema struct {
+    root *schemaNode
+}
+
+// SchemaViolation is an instance node that does not satisfy a schema keyword
+type SchemaViolation struct {
+    InstancePath string // json pointer of the node in the instance
+    SchemaPath   string // json pointer of the keyword in the schema
+    Line         int
+    Column       int
+    Msg          string
+}
+
+func (sv SchemaViolation) Error() string {
+    return fmt.Sprintf("[%d, %d], %s: %s (schema %s)", sv.Line, sv.Column, sv.InstancePath, sv.Msg, sv.SchemaPath)
+}
+
+type schemaNode struct {
+    path string
+
+    always *bool // boolean schema
+
+    types      []string
+    enum       []JsonAst
+    constValue *JsonAst
+
+    properties           map[string]*schemaNode
+    patternProperties    []patternSchema
+    additionalProperties *schemaNode
+    required             []string
+    minProperties        *int
+    maxProperties        *int
+
+    items       *schemaNode
+    prefixItems []*schemaNode
+    minItems    *int
+    maxItems    *int
+    uniqueItems bool
+
+    minLength *int
+    maxLength *int
+    pattern   *regexp.Regexp
+
+    minimum          *big.Float
+    maximum          *big.Float
+    exclusiveMinimum *big.Float
+    exclusiveMaximum *big.Float
+
+    allOf []*schemaNode
+    anyOf []*schemaNode
+    oneOf []*schemaNode
+    not   *schemaNode
+
+    ref       string
+    refTarget *schemaNode
+}
+
+type patternSchema struct {
+    re     *regexp.Regexp
+    schema *schemaNode
+}
+
+var schemaTypes = map[string]bool{
+    "null": true, "boolean": true, "object": true, "array": true,
+    "number": true, "integer": true, "string": true,
+}
+
+// CompileSchema parses the schema document with Parser and compiles it
+func CompileSchema(source string) (*Schema, error) {
+    doc, jerrs := Parser(source)
+    if len(jerrs) != 0 {
+        return nil, jerrs[0]
+    }
+    return CompileSchemaAst(doc)
+}
+
+// CompileSchemaAst compiles a schema document that is already parsed
+func CompileSchemaAst(doc JsonAst) (*Schema, error) {
+    var c = schemaCompiler{doc: doc, nodes: map[string]*schemaNode{}}
+    root, err := c.compile(doc, "")
+    if err != nil {
+        return nil, err
+    }
+
+    // compiling a target may add new references
+    for i := 0; i < len(c.refs); i++ {
+        var sn = c.refs[i]
+        if sn.refTarget, err = c.resolve(sn.ref, sn.path+"/$ref"); err != nil {
+            return nil, err
+        }
+    }
+
+    return &Schema{root: root}, nil
+}
+
+type schemaCompiler struct {
+    doc   JsonAst
+    nodes map[string]*schemaNode // by schema path
+    refs  []*schemaNode
+}
+
+func schemaError(ast JsonAst, path string, format string, args ...interface{}) error {
+    return fmt.Errorf("[%d, %d], %s: %s", ast.Loc.lineNum, ast.Loc.position, path, fmt.Sprintf(format, args...))
+}
+
+func (c *schemaCompiler) resolve(ref string, path string) (*schemaNode, error) {
+    if !strings.HasPrefix(ref, "#") {
+        return nil, fmt.Errorf("%s: unsupported non-local reference %s", path, quote(ref))
+    }
+    pointer, err := url.PathUnescape(ref[1:])
+    if err != nil {
+        return nil, fmt.Errorf("%s: invalid reference %s", path, quote(ref))
+    }
+    tokens, err := parsePointer(pointer)
+    if err != nil {
+        return nil, fmt.Errorf("%s: invalid reference %s", path, quote(ref))
+    }
+
+    // normalize the pointer so that it matches the paths of compiled nodes
+    var target = ""
+    for _, token := range tokens {
+        target += "/" + escapePointerToken(token)
+    }
+    if sn, ok := c.nodes[target]; ok {
+        return sn, nil
+    }
+    node, ok := lookupPointer(c.doc, tokens)
+    if !ok {
+        return nil, fmt.Errorf("%s: unresolvable reference %s", path, quote(ref))
+    }
+    return c.compile(node, target)
+}
+
+func (c *schemaCompiler) compile(node JsonAst, path string) (*schemaNode, error) {
+    if sn, ok := c.nodes[path]; ok {
+        return sn, nil
+    }
+
+    var sn = &schemaNode{path: path}
+    c.nodes[path] = sn
+
+    if node.Typ == Literal && node.LiteralAst.Typ == Boolean {
+        var b = node.LiteralAst.Val == "true"
+        sn.always = &b
+        return sn, nil
+    }
+    if node.Typ != Object {
+        return nil, schemaError(node, path, "schema must be an object or a boolean")
+    }
+
+    for k, v := range node.ObjectAst {
+        name, err := unquote(k)
+        if err != nil {
+            return nil, schemaError(v, path, "%v", err)
+        }
+        var kp = path + "/" + escapePointerToken(name)
+
+        switch name {
+        case "type":
+            if sn.types, err = stringList(v, kp); err == nil {
+                for _, t := range sn.types {
+                    if !schemaTypes[t] {
+                        err = schemaError(v, kp, "unknown type %s", quote(t))
+                    }
+                }
+            }
+        case "enum":
+            if v.Typ != Array {
+                err = schemaError(v, kp, "enum must be an array")
+            }
+            sn.enum = v.ArrayAst
+        case "const":
+            var cv = v
+            sn.constValue = &cv
+        case "properties", "$defs", "definitions":
+            if v.Typ != Object {
+                err = schemaError(v, kp, "%s must be an object", name)
+                break
+            }
+            var subs = map[string]*schemaNode{}
+            for pk, pv := range v.ObjectAst {
+                pname, _ := unquote(pk)
+                if subs[pname], err = c.compile(pv, kp+"/"+escapePointerToken(pname)); err != nil {
+                    return nil, err
+                }
+            }
+            if name == "properties" { sn.properties = subs }
+        case "patternProperties":
+            if v.Typ != Object {
+                err = schemaError(v, kp, "patternProperties must be an object")
+                break
+            }
+            for pk, pv := range v.ObjectAst {
+                pname, _ := unquote(pk)
+                var ps patternSchema
+                if ps.re, err = regexp.Compile(pname); err != nil {
+                    return nil, schemaError(pv, kp, "invalid pattern %s: %v", quote(pname), err)
+                }
+                if ps.schema, err = c.compile(pv, kp+"/"+escapePointerToken(pname)); err != nil {
+                    return nil, err
+                }
+                sn.patternProperties = append(sn.patternProperties, ps)
+            }
+            sort.Slice(sn.patternProperties, func(i, j int) bool {
+                return sn.patternProperties[i].schema.path < sn.patternProperties[j].schema.path
+            })
+        case "additionalProperties":
+            sn.additionalProperties, err = c.compile(v, kp)
+        case "required":
+            if v.Typ != Array {
+                err = schemaError(v, kp, "required must be an array of strings")
+                break
+            }
+            sn.required, err = stringList(v, kp)
+        case "items":
+            sn.items, err = c.compile(v, kp)
+        case "prefixItems":
+            sn.prefixItems, err = c.compileList(v, kp)
+        case "allOf":
+            sn.allOf, err = c.compileList(v, kp)
+        case "anyOf":
+            sn.anyOf, err = c.compileList(v, kp)
+        case "oneOf":
+            sn.oneOf, err = c.compileList(v, kp)
+        case "not":
+            sn.not, err = c.compile(v, kp)
+        case "minProperties": sn.minProperties, err = nonNegative(v, kp)
+        case "maxProperties": sn.maxProperties, err = nonNegative(v, kp)
+        case "minItems":      sn.minItems, err = nonNegative(v, kp)
+        case "maxItems":      sn.maxItems, err = nonNegative(v, kp)
+        case "minLength":     sn.minLength, err = nonNegative(v, kp)
+        case "maxLength":     sn.maxLength, err = nonNegative(v, kp)
+        case "minimum":          sn.minimum, err = schemaNumber(v, kp)
+        case "maximum":          sn.maximum, err = schemaNumber(v, kp)
+        case "exclusiveMinimum": sn.exclusiveMinimum, err = schemaNumber(v, kp)
+        case "exclusiveMaximum": sn.exclusiveMaximum, err = schemaNumber(v, kp)
+        case "uniqueItems":
+            if v.Typ != Literal || v.LiteralAst.Typ != Boolean {
+                err = schemaError(v, kp, "uniqueItems must be a boolean")
+            }
+            sn.uniqueItems = v.LiteralAst.Val == "true"
+        case "pattern":
+            if v.Typ != Literal || v.LiteralAst.Typ != String {
+                err = schemaError(v, kp, "pattern must be a string")
+                break
+            }
+            s, _ := unquote(v.LiteralAst.Val)
+            if sn.pattern, err = regexp.Compile(s); err != nil {
+                err = schemaError(v, kp, "invalid pattern %s: %v", quote(s), err)
+            }
+        case "$ref":
+            if v.Typ != Literal || v.LiteralAst.Typ != String {
+                err = schemaError(v, kp, "$ref must be a string")
+                break
+            }
+            sn.ref, _ = unquote(v.LiteralAst.Val)
+            c.refs = append(c.refs, sn)
+        }
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    return sn, nil
+}
+
+func (c *schemaCompiler) compileList(v JsonAst, path string) ([]*schemaNode, error) {
+    if v.Typ != Array || len(v.ArrayAst) == 0 {
+        return nil, schemaError(v, path, "must be a non-empty array of schemas")
+    }
+    var subs = make([]*schemaNode, len(v.ArrayAst))
+    for i, elem := range v.ArrayAst {
+        var err error
+        if subs[i], err = c.compile(elem, path+"/"+strconv.Itoa(i)); err != nil {
+            return nil, err
+        }
+    }
+    return subs, nil
+}
+
+func stringList(v JsonAst, path string) ([]string, error) {
+    var elems = []JsonAst{v}
+    if v.Typ == Array { elems = v.ArrayAst }
+
+    var list []string
+    for _, elem := range elems {
+        if elem.Typ != Literal || elem.LiteralAst.Typ != String {
+            return nil, schemaError(elem, path, "must be a string or an array of strings")
+        }
+        s, err := unquote(elem.LiteralAst.Val)
+        if err != nil {
+            return nil, schemaError(elem, path, "%v", err)
+        }
+        list = append(list, s)
+    }
+    return list, nil
+}
+
+func nonNegative(v JsonAst, path string) (*int, error) {
+    f, err := schemaNumber(v, path)
+    if err != nil {
+        return nil, err
+    }
+    n, acc := f.Int64()
+    if !f.IsInt() || f.Sign() < 0 || acc != big.Exact {
+        return nil, schemaError(v, path, "must be a non-negative integer")
+    }
+    var i = int(n)
+    return &i, nil
+}
+
+func schemaNumber(v JsonAst, path string) (*big.Float, error) {
+    if v.Typ != Literal || v.LiteralAst.Typ != Number {
+        return nil, schemaError(v, path, "must be a number")
+    }
+    f, ok := bigNumber(v.LiteralAst.Val)
+    if !ok {
+        return nil, schemaError(v, path, "invalid number %s", v.LiteralAst.Val)
+    }
+    return f, nil
+}
+
+// bigNumber parses json number text exactly enough to compare numbers
+func bigNumber(raw string) (*big.Float, bool) {
+    f, _, err := big.ParseFloat(raw, 10, uint(len(raw))*4+64, big.ToNearestEven)
+    return f, err == nil
+}
+
+// orderedNumber is the number for comparisons with bounds. a valid json number whose exponent
+// is out of the range of big.Float is taken as ±Inf when it is positive, and as the smallest
+// number of its sign when it is negative: either compares with any bound as the number does
+func orderedNumber(raw string) (*big.Float, bool) {
+    if f, ok := bigNumber(raw); ok {
+        return f, true
+    }
+    var e = strings.IndexAny(raw, "eE")
+    if e < 0 || e+1 == len(raw) {
+        return nil, false
+    }
+    var neg = raw[0] == '-'
+    if raw[e+1] != '-' {
+        return new(big.Float).SetInf(neg), true
+    }
+    var f = new(big.Float).SetMantExp(big.NewFloat(0.5), big.MinExp)
+    if neg { f.Neg(f) }
+    return f, true
+}
+
+// equalJSON reports whether a and b are the same json value: numbers are
+// compared by value, strings and member names after unescaping
+func equalJSON(a, b JsonAst) bool {
+    if a.Typ != b.Typ {
+        return false
+    }
+
+    switch a.Typ {
+    case Object:
+        if len(a.ObjectAst) != len(b.ObjectAst) {
+            return false
+        }
+        for k, av := range a.ObjectAst {
+            name, _ := unquote(k)
+            bv, ok := member(b, name)
+            if !ok || !equalJSON(av, bv) {
+                return false
+            }
+        }
+        return true
+    case Array:
+        if len(a.ArrayAst) != len(b.ArrayAst) {
+            return false
+        }
+        for i := range a.ArrayAst {
+            if !equalJSON(a.ArrayAst[i], b.ArrayAst[i]) {
+                return false
+            }
+        }
+        return true
+    }
+
+    var at, bt = a.LiteralAst.Typ, b.LiteralAst.Typ
+    if at != bt {
+        return false
+    }
+    switch at {
+    case Number:
+        af, aok := bigNumber(a.LiteralAst.Val)
+        bf, bok := bigNumber(b.LiteralAst.Val)
+        if !aok || !bok { // out of range, compared as written
+            return !aok && !bok && a.LiteralAst.Val == b.LiteralAst.Val
+        }
+        return af.Cmp(bf) == 0
+    case String:
+        as, _ := unquote(a.LiteralAst.Val)
+        bs, _ := unquote(b.LiteralAst.Val)
+        return as == bs
+    }
+    return a.LiteralAst.Val == b.LiteralAst.Val
+}
+
+// Validate checks the instance against the schema, violations are reported in document order
+func (s *Schema) Validate(instance JsonAst) []SchemaViolation {
+    var v validation
+    v.validate(s.root, instance, "")
+    sort.SliceStable(v.violations, func(i, j int) bool {
+        var x, y = v.violations[i], v.violations[j]
+        if x.Line != y.Line { return x.Line < y.Line }
+        return x.Column < y.Column
+    })
+    return v.violations
+}
+
+// maxRefDepth guards against references that loop without descending into the instance
+const maxRefDepth = 256
+
+type validation struct {
+    violations []SchemaViolation
+    refDepth   int
+}
+
+func (v *validation) report(ast JsonAst, ipath string, spath string, format string, args ...interface{}) {
+    v.violations = append(v.violations, SchemaViolation{
+        InstancePath: ipath,
+        SchemaPath:   spath,
+        Line:         ast.Loc.lineNum,
+        Column:       ast.Loc.position,
+        Msg:          fmt.Sprintf(format, args...),
+    })
+}
+
+// matches validates against sn without recording the violations
+func (v *validation) matches(sn *schemaNode, ast JsonAst, ipath string) bool {
+    var sub = validation{refDepth: v.refDepth}
+    sub.validate(sn, ast, ipath)
+    return len(sub.violations) == 0
+}
+
+func jsonType(ast JsonAst) string {
+    switch ast.Typ {
+    case Object: return "object"
+    case Array:  return "array"
+    }
+    switch ast.LiteralAst.Typ {
+    case String:  return "string"
+    case Number:  return "number"
+    case Boolean: return "boolean"
+    }
+    return "null"
+}
+
+func (v *validation) validate(sn *schemaNode, ast JsonAst, ipath string) {
+    if sn.always != nil {
+        if !*sn.always {
+            v.report(ast, ipath, sn.path, "no value is allowed by the false schema")
+        }
+        return
+    }
+
+    if sn.refTarget != nil {
+        if v.refDepth >= maxRefDepth {
+            v.report(ast, ipath, sn.path+"/$ref", "reference %s nests too deep", quote(sn.ref))
+        } else {
+            v.refDepth++
+            v.validate(sn.refTarget, ast, ipath)
+            v.refDepth--
+        }
+    }
+
+    var typ = jsonType(ast)
+    if sn.types != nil {
+        var ok = false
+        for _, t := range sn.types {
+            if t == typ || t == "integer" && typ == "number" && isInteger(ast) {
+                ok = true
+                break
+            }
+        }
+        if !ok {
+            v.report(ast, ipath, sn.path+"/type", "%s is not of type %s", typ, strings.Join(sn.types, ", "))
+        }
+    }
+
+    if sn.enum != nil {
+        var ok = false
+        for _, e := range sn.enum {
+            if equalJSON(ast, e) {
+                ok = true
+                break
+            }
+        }
+        if !ok {
+            v.report(ast, ipath, sn.path+"/enum", "value is not one of the enumerated values")
+        }
+    }
+    if sn.constValue != nil && !equalJSON(ast, *sn.constValue) {
+        v.report(ast, ipath, sn.path+"/const", "value is not equal to the constant")
+    }
+
+    switch typ {
+    case "object": v.validateObject(sn, ast, ipath)
+    case "array":  v.validateArray(sn, ast, ipath)
+    case "string": v.validateString(sn, ast, ipath)
+    case "number": v.validateNumber(sn, ast, ipath)
+    }
+
+    for _, sub := range sn.allOf {
+        v.validate(sub, ast, ipath)
+    }
+    if sn.anyOf != nil {
+        var ok = false
+        for _, sub := range sn.anyOf {
+            if v.matches(sub, ast, ipath) {
+                ok = true
+                break
+            }
+        }
+        if !ok {
+            v.report(ast, ipath, sn.path+"/anyOf", "value does not match any of the subschemas")
+        }
+    }
+    if sn.oneOf != nil {
+        var n = 0
+        for _, sub := range sn.oneOf {
+            if v.matches(sub, ast, ipath) { n++ }
+        }
+        if n != 1 {
+            v.report(ast, ipath, sn.path+"/oneOf", "value matches %d of the subschemas, exactly one expected", n)
+        }
+    }
+    if sn.not != nil && v.matches(sn.not, ast, ipath) {
+        v.report(ast, ipath, sn.path+"/not", "value matches the schema it must not match")
+    }
+}
+
+func isInteger(ast JsonAst) bool {
+    var raw = ast.LiteralAst.Val
+    if f, ok := bigNumber(raw); ok {
+        return f.IsInt()
+    }
+    // the exponent is out of range: a positive one leaves no fraction, a negative one
+    // leaves a fraction of the mantissa, which is not 0 (0 is in range)
+    var e = strings.IndexAny(raw, "eE")
+    return e >= 0 && e+1 < len(raw) && raw[e+1] != '-'
+}
+
+func (v *validation) validateObject(sn *schemaNode, ast JsonAst, ipath string) {
+    var names = make([]string, 0, len(ast.ObjectAst))
+    var values = make(map[string]JsonAst, len(ast.ObjectAst))
+    for k, member := range ast.ObjectAst {
+        name, _ := unquote(k)
+        names = append(names, name)
+        values[name] = member
+    }
+    sort.Strings(names)
+
+    for _, name := range sn.required {
+        if _, ok := values[name]; !ok {
+            v.report(ast, ipath, sn.path+"/required", "missing required property %s", quote(name))
+        }
+    }
+    if sn.minProperties != nil && len(names) < *sn.minProperties {
+        v.report(ast, ipath, sn.path+"/minProperties", "%d properties, at least %d expected", len(names), *sn.minProperties)
+    }
+    if sn.maxProperties != nil && len(names) > *sn.maxProperties {
+        v.report(ast, ipath, sn.path+"/maxProperties", "%d properties, at most %d expected", len(names), *sn.maxProperties)
+    }
+
+    for _, name := range names {
+        var mpath = ipath + "/" + escapePointerToken(name)
+        var evaluated = false
+        if sub, ok := sn.properties[name]; ok {
+            evaluated = true
+            v.validate(sub, values[name], mpath)
+        }
+        for _, ps := range sn.patternProperties {
+            if ps.re.MatchString(name) {
+                evaluated = true
+                v.validate(ps.schema, values[name], mpath)
+            }
+        }
+        if !evaluated && sn.additionalProperties != nil {
+            v.validate(sn.additionalProperties, values[name], mpath)
+        }
+    }
+}
+
+func (v *validation) validateArray(sn *schemaNode, ast JsonAst, ipath string) {
+    var n = len(ast.ArrayAst)
+    if sn.minItems != nil && n < *sn.minItems {
+        v.report(ast, ipath, sn.path+"/minItems", "%d items, at least %d expected", n, *sn.minItems)
+    }
+    if sn.maxItems != nil && n > *sn.maxItems {
+        v.report(ast, ipath, sn.path+"/maxItems", "%d items, at most %d expected", n, *sn.maxItems)
+    }
+    if sn.uniqueItems {
+    unique:
+        for i := 0; i < n; i++ {
+            for j := i + 1; j < n; j++ {
+                if equalJSON(ast.ArrayAst[i], ast.ArrayAst[j]) {
+                    v.report(ast.ArrayAst[j], ipath+"/"+strconv.Itoa(j), sn.path+"/uniqueItems", "item duplicates item %d", i)
+                    break unique
+                }
+            }
+        }
+    }
+
+    for i, elem := range ast.ArrayAst {
+        var epath = ipath + "/" + strconv.Itoa(i)
+        if i < len(sn.prefixItems) {
+            v.validate(sn.prefixItems[i], elem, epath)
+        } else if sn.items != nil {
+            v.validate(sn.items, elem, epath)
+        }
+    }
+}
+
+func (v *validation) validateString(sn *schemaNode, ast JsonAst, ipath string) {
+    s, _ := unquote(ast.LiteralAst.Val)
+    var n = utf8.RuneCountInString(s)
+    if sn.minLength != nil && n < *sn.minLength {
+        v.report(ast, ipath, sn.path+"/minLength", "length %d, at least %d expected", n, *sn.minLength)
+    }
+    if sn.maxLength != nil && n > *sn.maxLength {
+        v.report(ast, ipath, sn.path+"/maxLength", "length %d, at most %d expected", n, *sn.maxLength)
+    }
+    if sn.pattern != nil && !sn.pattern.MatchString(s) {
+        v.report(ast, ipath, sn.path+"/pattern", "%s does not match pattern %s", quote(s), quote(sn.pattern.String()))
+    }
+}
+
+func (v *validation) validateNumber(sn *schemaNode, ast JsonAst, ipath string) {
+    var raw = ast.LiteralAst.Val
+    f, ok := orderedNumber(raw)
+    if !ok {
+        v.report(ast, ipath, sn.path, "%s is not a number which can be compared", raw)
+        return
+    }
+    if sn.minimum != nil && f.Cmp(sn.minimum) < 0 {
+        v.report(ast, ipath, sn.path+"/minimum", "%s is less than %s", raw, sn.minimum.Text('g', -1))
+    }
+    if sn.maximum != nil && f.Cmp(sn.maximum) > 0 {
+        v.report(ast, ipath, sn.path+"/maximum", "%s is greater than %s", raw, sn.maximum.Text('g', -1))
+    }
+    if sn.exclusiveMinimum != nil && f.Cmp(sn.exclusiveMinimum) <= 0 {
+        v.report(ast, ipath, sn.path+"/exclusiveMinimum", "%s is not greater than %s", raw, sn.exclusiveMinimum.Text('g', -1))
+    }
+    if sn.exclusiveMaximum != nil && f.Cmp(sn.exclusiveMaximum) >= 0 {
+        v.report(ast, ipath, sn.path+"/exclusiveMaximum", "%s is not less than %s", raw, sn.exclusiveMaximum.Text('g', -1))
+    }
+}
@@ -0,0 +1,145 @@
+package json2ast
+
+import (
+    "strings"
+    "testing"
+)
+
+const testSchema = `{
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "type": "object",
+    "required": ["name", "port"],
+    "properties": {
+        "name": {"type": "string", "minLength": 2, "pattern": "^[a-z]+$"},
+        "port": {"type": "integer", "minimum": 1, "exclusiveMaximum": 65536},
+        "mode": {"enum": ["dev", "prod", 1.0]},
+        "version": {"const": {"major": 1}},
+        "tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}, "maxItems": 3, "uniqueItems": true},
+        "target": {"oneOf": [{"type": "string"}, {"type": "number"}]},
+        "limit": {"anyOf": [{"type": "null"}, {"$ref": "#/$defs/positive"}]},
+        "child": {"$ref": "#"},
+        "both": {"allOf": [{"type": "number"}, {"$ref": "#/$defs/positive"}]}
+    },
+    "additionalProperties": false,
+    "$defs": {
+        "tag": {"type": "string", "maxLength": 3},
+        "positive": {"type": "number", "exclusiveMinimum": 0}
+    }
+}`
+
+func TestSchemaValidate(t *testing.T) {
+    schema, err := CompileSchema(testSchema)
+    if err != nil {
+        t.Fatalf("compile schema failed: %v", err)
+    }
+
+    validTests := []string {
+        `{"name": "api", "port": 8080}`,
+        `{"name": "api", "port": 8.08e3, "mode": 1, "version": {"major": 1.0}, "tags": ["a", "b"],
+          "target": "x", "limit": null, "child": {"name": "db", "port": 1}, "both": 0.5}`,
+    }
+    for _, vt := range validTests {
+        ast, jerrs := Parser(vt)
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed", vt)
+        }
+        if vs := schema.Validate(ast); len(vs) != 0 {
+            t.Fatalf("validate `%s` gives %v", vt, vs)
+        }
+    }
+
+    invalid := `{
+    "name": "API",
+    "port": 65536,
+    "mode": "test",
+    "version": {"major": 2},
+    "tags": ["a", "long", "a", "b"],
+    "target": true,
+    "limit": -1,
+    "child": {"port": 0.5},
+    "both": "x",
+    "extra": 1
+}`
+    want := []SchemaViolation {
+        {"/name", "/properties/name/pattern", 2, 13, ""},
+        {"/port", "/properties/port/exclusiveMaximum", 3, 13, ""},
+        {"/mode", "/properties/mode/enum", 4, 13, ""},
+        {"/version", "/properties/version/const", 5, 16, ""},
+        {"/tags", "/properties/tags/maxItems", 6, 13, ""},
+        {"/tags/1", "/$defs/tag/maxLength", 6, 19, ""},
+        {"/tags/2", "/properties/tags/uniqueItems", 6, 27, ""},
+        {"/target", "/properties/target/oneOf", 7, 15, ""},
+        {"/limit", "/properties/limit/anyOf", 8, 14, ""},
+        {"/child", "/required", 9, 14, ""},
+        {"/child/port", "/properties/port/type", 9, 23, ""},
+        {"/child/port", "/properties/port/minimum", 9, 23, ""},
+        {"/both", "/properties/both/allOf/0/type", 10, 13, ""},
+        {"/both", "/$defs/positive/type", 10, 13, ""},
+        {"/extra", "/additionalProperties", 11, 14, ""},
+    }
+
+    ast, jerrs := Parser(invalid)
+    if len(jerrs) != 0 {
+        t.Fatalf("build AST for `%s` failed", invalid)
+    }
+    got := schema.Validate(ast)
+    if len(got) != len(want) {
+        t.Fatalf("validate gives %d violations, want %d: %v", len(got), len(want), got)
+    }
+    for i := range want {
+        g, w := got[i], want[i]
+        if g.InstancePath != w.InstancePath || g.SchemaPath != w.SchemaPath || g.Line != w.Line || g.Column != w.Column {
+            t.Fatalf("violation %d is %v, want %s %s [%d, %d]", i, g, w.InstancePath, w.SchemaPath, w.Line, w.Column)
+        }
+    }
+}
+
+// numbers whose exponent is out of the range of big.Float are still compared
+func TestSchemaHugeNumbers(t *testing.T) {
+    schema, err := CompileSchema(`{"type": "integer", "minimum": -10, "maximum": 10, "exclusiveMinimum": 0}`)
+    if err != nil {
+        t.Fatalf("compile schema failed: %v", err)
+    }
+    tests := []struct {
+        source string
+        want   []string
+    }{
+        {`1e99999999999`, []string{"/maximum"}},
+        {`-1E+99999999999`, []string{"/minimum", "/exclusiveMinimum"}},
+        {`1e-99999999999`, []string{"/type"}},
+        {`-1e-99999999999`, []string{"/type", "/exclusiveMinimum"}},
+    }
+    for _, test := range tests {
+        ast, jerrs := Parser(test.source)
+        if len(jerrs) != 0 {
+            t.Fatalf("build AST for `%s` failed", test.source)
+        }
+        var got []string
+        for _, v := range schema.Validate(ast) {
+            got = append(got, v.SchemaPath)
+        }
+        if strings.Join(got, " ") != strings.Join(test.want, " ") {
+            t.Errorf("validate `%s` gives %v, want %v", test.source, got, test.want)
+        }
+    }
+}
+
+func TestCompileSchemaError(t *testing.T) {
+    invalidTests := []string {
+        `[]`,
+        `{"type": "float"}`,
+        `{"required": "name"}`,
+        `{"minLength": -1}`,
+        `{"pattern": "(["}`,
+        `{"anyOf": []}`,
+        `{"$ref": "#/$defs/missing"}`,
+        `{"$ref": "https://example.com/schema"}`,
+        `{"properties": {"a": 1}}`,
+        `{"type": }`,
+    }
+    for _, ivt := range invalidTests {
+        if _, err := CompileSchema(ivt); err == nil {
+            t.Fatalf("compile `%s` should fail", ivt)
+        }
+    }
+}
@@ -0,0 +1,410 @@
+package json2ast
+
+import (
+    "errors"
+    "io"
+    "unicode/utf8"
+)
+
+// streamChunk is how many bytes are read from the io.Reader at a time
+var streamChunk = 64 << 10
+
+// streamReader reads json text from an io.Reader, keeping in its buffer only the bytes not
+// consumed yet and the text being kept. it counts lines and columns as the lexer does
+type streamReader struct {
+    r        io.Reader
+    buf      []byte
+    base     int // offset in the stream of buf[0]
+    pos      int // next byte in buf
+    keep     int // start in buf of the text being kept, -1 when none is
+    line     int
+    col      int
+    utf16    bool
+    limit    int   // Options.MaxBytes
+    exceeded bool  // a byte past limit was found
+    err      error // of the io.Reader, io.EOF at the end of the stream
+}
+
+// more reads more of the stream, it returns false at the end of it or on an error
+func (in *streamReader) more() bool {
+    if in.err != nil {
+        return false
+    }
+    var drop = in.pos
+    if in.keep >= 0 { drop, in.keep = in.keep, 0 }
+    in.buf = append(in.buf[:0], in.buf[drop:]...)
+    in.base, in.pos = in.base+drop, in.pos-drop
+    if cap(in.buf)-len(in.buf) < streamChunk {
+        var buf = make([]byte, len(in.buf), 2*cap(in.buf)+streamChunk)
+        copy(buf, in.buf)
+        in.buf = buf
+    }
+    n, err := in.r.Read(in.buf[len(in.buf):cap(in.buf)])
+    in.buf = in.buf[:len(in.buf)+n]
+    if err != nil { in.err = err }
+    return true
+}
+
+// peek is the next byte, ok is false at the end of the stream and past Options.MaxBytes
+func (in *streamReader) peek() (c byte, ok bool) {
+    for in.pos >= len(in.buf) {
+        if !in.more() { return 0, false }
+    }
+    if in.limit > 0 && in.base+in.pos >= in.limit {
+        in.exceeded = true
+        return 0, false
+    }
+    return in.buf[in.pos], true
+}
+
+// advance consumes the rune at the next byte, there must be one
+func (in *streamReader) advance() {
+    var c = in.buf[in.pos]
+    if c < utf8.RuneSelf {
+        in.pos++
+        in.col++
+        if c == '\n' { in.line, in.col = in.line+1, 1 }
+        return
+    }
+    for !utf8.FullRune(in.buf[in.pos:]) && in.more() {}
+    r, size := utf8.DecodeRune(in.buf[in.pos:])
+    in.pos += size
+    in.col++
+    if in.utf16 && r > 0xFFFF { in.col++ } // a surrogate pair
+}
+
+func (in *streamReader) position() Position {
+    return Position{in.base + in.pos, in.line, in.col}
+}
+
+func (in *streamReader) location() location {
+    return location{in.line, in.col}
+}
+
+func (in *streamReader) skipSpace() {
+    for c, ok := in.peek(); ok && isSpace(rune(c)); c, ok = in.peek() {
+        in.advance()
+    }
+}
+
+// value consumes the json value at the next byte, looking only at strings and brackets
+// as splitArray does, and returns its text when keep is true
+func (in *streamReader) value(keep bool) string {
+    if keep { in.keep = in.pos }
+    var c, ok = in.peek()
+    switch {
+    case !ok:
+    case c == '{' || c == '[':
+        for depth := 0; ok; c, ok = in.peek() {
+            in.advance()
+            switch c {
+            case '{', '[':
+                depth++
+            case '}', ']':
+                depth--
+            case '"':
+                in.skipString()
+            }
+            if depth == 0 { break }
+        }
+    case c == '"':
+        in.advance()
+        in.skipString()
+    default:
+        for ; ok && !isDelimiter(rune(c)); c, ok = in.peek() {
+            in.advance()
+        }
+    }
+    if !keep {
+        return ""
+    }
+    var text = string(in.buf[in.keep:in.pos])
+    in.keep = -1
+    return text
+}
+
+// skipString consumes the rest of a string whose opening quote is consumed
+func (in *streamReader) skipString() {
+    for c, ok := in.peek(); ok; c, ok = in.peek() {
+        in.advance()
+        if c == '"' { return }
+        if c == '\\' {
+            if _, ok := in.peek(); ok { in.advance() }
+        }
+    }
+}
+
+// ArrayIter reads the elements of a json array one at a time from an io.Reader, keeping
+// only the element being read in memory:
+//
+//  var iter = NewArrayIter(r, "/data")
+//  for iter.Next() {
+//      node := iter.Node()
+//  }
+//  if iter.Err() != nil || len(iter.Errs()) != 0 { ... }
+//
+// the elements are parsed by the parser, with their positions in the whole stream. the
+// values off the way to the array are only skipped by matching brackets, they are not
+// checked, and what follows an array which is not the top-level value is not read
+type ArrayIter struct {
+    in      *streamReader
+    pointer string
+    opts    Options
+    depth   int // of the array
+    count   int // elements read
+    started bool
+    done    bool
+    node    JsonAst
+    errs    []jsonError
+    err     error
+}
+
+// NewArrayIter returns an ArrayIter over the array referenced by the json pointer (RFC 6901),
+// "" is the top-level value
+func NewArrayIter(r io.Reader, pointer string) *ArrayIter {
+    return NewArrayIterWithOptions(r, pointer, Options{})
+}
+
+// NewArrayIterWithOptions returns an ArrayIter reading as opts says. MaxBytes stops the
+// reading at that many bytes, MaxTokens and MaxStringLength apply to each element and
+// MaxDepth counts the objects and arrays around the elements. Parallel is not used
+func NewArrayIterWithOptions(r io.Reader, pointer string, opts Options) *ArrayIter {
+    var in = &streamReader{r: r, keep: -1, line: 1, col: 1, utf16: opts.UTF16Columns, limit: opts.MaxBytes}
+    return &ArrayIter{in: in, pointer: pointer, opts: opts}
+}
+
+// Next reads the next element, it returns false after the last one or on an error
+func (it *ArrayIter) Next() bool {
+    if it.done {
+        return false
+    }
+    if !it.started {
+        it.started = true
+        if !it.find() || !it.open() { return it.stop() }
+        if c, ok := it.in.peek(); ok && c == ']' {
+            it.in.advance()
+            it.end()
+            return it.stop()
+        }
+    } else {
+        var in = it.in
+        in.skipSpace()
+        var c, ok = in.peek()
+        switch {
+        case ok && c == ']':
+            in.advance()
+            it.end()
+            return it.stop()
+        case ok && c == ',':
+            var comma = in.location()
+            in.advance()
+            in.skipSpace()
+            if c, ok := in.peek(); ok && c == ']' {
+                it.fail(TrailingComma, comma)
+                return it.stop()
+            }
+        default:
+            it.fail(CommaOrClosingBracketExpected, it.here())
+            return it.stop()
+        }
+    }
+    return it.element()
+}
+
+// Node is the element read by Next
+func (it *ArrayIter) Node() JsonAst {
+    return it.node
+}
+
+// Errs are the syntax errors which stopped the iteration
+func (it *ArrayIter) Errs() []jsonError {
+    return it.errs
+}
+
+// Err is the error of the io.Reader, or why the json pointer references no array
+func (it *ArrayIter) Err() error {
+    return it.err
+}
+
+func (it *ArrayIter) stop() bool {
+    it.done, it.node = true, JsonAst{}
+    if it.in.err != nil && it.in.err != io.EOF { it.err = it.in.err }
+    return false
+}
+
+func (it *ArrayIter) fail(typ ErrorType, loc location) {
+    if it.in.exceeded { typ, loc = TooLarge, it.in.location() }
+    it.errs = append(it.errs, jsonError{typ, loc})
+}
+
+// here is the location of the next byte, {-1, -1} at the end of the stream as for the parser
+func (it *ArrayIter) here() location {
+    if _, ok := it.in.peek(); !ok {
+        return location{-1, -1}
+    }
+    return it.in.location()
+}
+
+// element parses the element at the next byte
+func (it *ArrayIter) element() bool {
+    var in = it.in
+    var start = in.position()
+    it.count++
+    if it.opts.MaxMembers > 0 && it.count > it.opts.MaxMembers {
+        it.fail(TooManyMembers, it.here())
+        return it.stop()
+    }
+    var text = in.value(true)
+    if in.exceeded || text == "" {
+        it.fail(ValueExpected, it.here())
+        return it.stop()
+    }
+
+    var opts = it.opts
+    opts.MaxBytes = 0
+    var b = &astBuilder{}
+    var p = &parser{
+        scanner: newScannerAt(text, opts, Position{0, start.Line, start.Column}),
+        limits: opts,
+        containers: make([]container, it.depth),
+        build: b,
+    }
+    if errs := p.run(symbol{nonterminal, 0}); len(errs) != 0 {
+        it.errs = errs
+        return it.stop()
+    }
+    it.node = b.values[0]
+    return true
+}
+
+// find goes to the array referenced by the json pointer, skipping the values before it
+func (it *ArrayIter) find() bool {
+    tokens, err := parsePointer(it.pointer)
+    if err != nil {
+        it.err = err
+        return false
+    }
+    var in = it.in
+    for _, token := range tokens {
+        in.skipSpace()
+        var c, ok = in.peek()
+        if !ok {
+            it.fail(ValueExpected, it.here())
+            return false
+        }
+        if (c == '{' || c == '[') && !it.enter() {
+            return false
+        }
+        var found bool
+        switch c {
+        case '{':
+            found = it.findMember(token)
+        case '[':
+            if i, ok := arrayIndex(token); ok { found = it.findElement(i) }
+        }
+        if !found {
+            if len(it.errs) == 0 {
+                it.err = errors.New("no array at json pointer " + it.pointer)
+            }
+            return false
+        }
+    }
+    return true
+}
+
+// enter consumes the opening brace or bracket of a container on the way to the array
+func (it *ArrayIter) enter() bool {
+    it.depth++
+    if it.opts.MaxDepth > 0 && it.depth > it.opts.MaxDepth {
+        it.fail(TooDeep, it.in.location())
+        return false
+    }
+    it.in.advance()
+    return true
+}
+
+// open consumes the opening bracket of the array
+func (it *ArrayIter) open() bool {
+    var in = it.in
+    in.skipSpace()
+    if c, ok := in.peek(); !ok {
+        it.fail(ValueExpected, it.here())
+        return false
+    } else if c != '[' {
+        it.err = errors.New("no array at json pointer " + it.pointer)
+        return false
+    }
+    if !it.enter() {
+        return false
+    }
+    in.skipSpace()
+    return true
+}
+
+// end checks that nothing follows the array when it is the top-level value
+func (it *ArrayIter) end() {
+    if it.pointer != "" {
+        return
+    }
+    it.in.skipSpace()
+    if _, ok := it.in.peek(); ok || it.in.exceeded {
+        it.fail(EndOfJsonExpected, it.in.location())
+    }
+}
+
+// findMember goes to the value of the first member with the name, in the object whose brace is consumed
+func (it *ArrayIter) findMember(name string) bool {
+    var in = it.in
+    for {
+        in.skipSpace()
+        if c, ok := in.peek(); ok && c == '}' {
+            return false
+        } else if !ok || c != '"' {
+            it.fail(PropertyOrClosingBraceExpected, it.here())
+            return false
+        }
+        var key = in.value(true)
+        in.skipSpace()
+        if c, ok := in.peek(); !ok || c != ':' {
+            it.fail(ColonExpected, it.here())
+            return false
+        }
+        in.advance()
+        if s, err := unquote(key); err == nil && s == name {
+            return true
+        }
+        in.skipSpace()
+        in.value(false)
+        in.skipSpace()
+        if c, ok := in.peek(); !ok || c != ',' && c != '}' {
+            it.fail(CommaOrClosingBraceExpected, it.here())
+            return false
+        } else if c == '}' {
+            return false
+        }
+        in.advance()
+    }
+}
+
+// findElement goes to the i-th element of the array whose bracket is consumed
+func (it *ArrayIter) findElement(i int) bool {
+    var in = it.in
+    for ; ; i-- {
+        in.skipSpace()
+        if c, ok := in.peek(); ok && c == ']' {
+            return false
+        }
+        if i == 0 {
+            return true
+        }
+        in.value(false)
+        in.skipSpace()
+        if c, ok := in.peek(); !ok || c != ',' && c != ']' {
+            it.fail(CommaOrClosingBracketExpected, it.here())
+            return false
+        } else if c == ']' {
+            return false
+        }
+        in.advance()
+    }
+}
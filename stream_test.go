@@ -0,0 +1,148 @@
+package json2ast
+
+import (
+    "errors"
+    "io"
+    "reflect"
+    "strings"
+    "testing"
+    "testing/iotest"
+)
+
+// elements reads all the elements of the array with an ArrayIter
+func elements(r io.Reader, pointer string, opts Options) ([]JsonAst, []jsonError, error) {
+    var nodes []JsonAst
+    var iter = NewArrayIterWithOptions(r, pointer, opts)
+    for iter.Next() {
+        nodes = append(nodes, iter.Node())
+    }
+    return nodes, iter.Errs(), iter.Err()
+}
+
+// the elements are those Parser gives, read one byte at a time or all at once
+func TestArrayIterMatchesAst(t *testing.T) {
+    var sources = []string{
+        "[]",
+        " \n[ 1 , \"a\\\"]\", {\"b\": [true, null]},\r\n\t[[]], -0.5e3, \"😀é\", {} ] \n",
+        "[\"\xff\", \"\\ud800\", \"x\"]",
+        benchmarkDocument(),
+    }
+    var options = []Options{{}, {UTF16Columns: true, ReplaceInvalid: true}}
+    for _, source := range sources {
+        for _, opts := range options {
+            ast, jerrs := ParserWithOptions(source, opts)
+            var readers = []io.Reader{strings.NewReader(source)}
+            if len(source) < 1000 { readers = append(readers, iotest.OneByteReader(strings.NewReader(source))) }
+            for _, r := range readers {
+                nodes, errs, err := elements(r, "", opts)
+                if jerrs != nil {
+                    if len(errs) == 0 { t.Errorf("%.40q has no errors, Parser %v", source, jerrs) }
+                    continue
+                }
+                if err != nil || len(errs) != 0 || len(nodes) != len(ast.ArrayAst) {
+                    t.Errorf("%.40q gives %d elements, %v %v", source, len(nodes), errs, err)
+                    continue
+                }
+                for i := range nodes {
+                    if !reflect.DeepEqual(nodes[i], ast.ArrayAst[i]) {
+                        t.Errorf("%.40q element %d is %+v, Parser %+v", source, i, nodes[i], ast.ArrayAst[i])
+                    }
+                }
+            }
+        }
+    }
+}
+
+func TestArrayIterPointer(t *testing.T) {
+    var source = `{"meta": {"a": [1]}, "data": [{"x": "]"}, {"items": [
+        "é", 2]}], "data": [3]}`
+    nodes, errs, err := elements(iotest.OneByteReader(strings.NewReader(source)), "/data/1/items", Options{})
+    if err != nil || len(errs) != 0 || len(nodes) != 2 {
+        t.Fatalf("elements are %+v, %v %v", nodes, errs, err)
+    }
+    if nodes[0].LiteralAst.Val != `"é"` || nodes[0].Loc != (location{2, 9}) || nodes[1].Loc != (location{2, 14}) {
+        t.Errorf("elements are %+v", nodes)
+    }
+
+    for _, pointer := range []string{"/meta", "/meta/b", "/data/2", "/data/0/x", "data"} {
+        if _, _, err := elements(strings.NewReader(source), pointer, Options{}); err == nil {
+            t.Errorf("%s references an array", pointer)
+        }
+    }
+}
+
+// the iteration stops at the first error, found where Parser finds it
+func TestArrayIterErrors(t *testing.T) {
+    var tests = []struct {
+        source string
+        opts   Options
+        count  int // elements read before the error
+        want   jsonError
+    }{
+        {"[1, 2 3]", Options{}, 2, jsonError{CommaOrClosingBracketExpected, location{1, 7}}},
+        {"[1,\n {\"a\": }]", Options{}, 1, jsonError{ValueExpected, location{2, 8}}},
+        {"[1, 2,\n]", Options{}, 2, jsonError{TrailingComma, location{1, 6}}},
+        {"[1,,2]", Options{}, 1, jsonError{ValueExpected, location{1, 4}}},
+        {"[1, tru]", Options{}, 1, jsonError{InvalidToken, location{1, 5}}},
+        {"[1] 2", Options{}, 1, jsonError{EndOfJsonExpected, location{1, 5}}},
+        {"[1, 2", Options{}, 2, jsonError{CommaOrClosingBracketExpected, location{-1, -1}}},
+        {"", Options{}, 0, jsonError{ValueExpected, location{-1, -1}}},
+        {"[1, 2, 3]", Options{MaxMembers: 2}, 2, jsonError{TooManyMembers, location{1, 8}}},
+        {"[1, [2]]", Options{MaxDepth: 1}, 1, jsonError{TooDeep, location{1, 5}}},
+        {"[1, 22, 3]", Options{MaxBytes: 6}, 1, jsonError{TooLarge, location{1, 7}}},
+    }
+    for _, test := range tests {
+        nodes, errs, err := elements(strings.NewReader(test.source), "", test.opts)
+        if err != nil || len(nodes) != test.count || len(errs) == 0 || errs[0] != test.want {
+            t.Errorf("%q gives %d elements, %v %v, want %v", test.source, len(nodes), errs, err, test.want)
+        }
+        if _, jerrs := ParserWithOptions(test.source, test.opts); len(jerrs) == 0 || jerrs[0] != test.want {
+            t.Errorf("%q gives %v with Parser", test.source, jerrs)
+        }
+    }
+
+    var broken = errors.New("broken")
+    _, _, err := elements(io.MultiReader(strings.NewReader("[1, "), iotest.ErrReader(broken)), "", Options{})
+    if err != broken {
+        t.Errorf("the error of the reader is %v", err)
+    }
+}
+
+// elementsReader is a top-level array of n elements, made as it is read
+type elementsReader struct {
+    n    int
+    text string
+}
+
+func (r *elementsReader) Read(p []byte) (int, error) {
+    if r.text == "" {
+        switch {
+        case r.n < 0:
+            return 0, io.EOF
+        case r.n == 0:
+            r.text = "{}]"
+        default:
+            r.text = `{"id": 1, "tags": ["a", "b"]},` + "\n"
+        }
+        r.n--
+    }
+    var n = copy(p, r.text)
+    r.text = r.text[n:]
+    return n, nil
+}
+
+// memory is bounded by an element and a chunk of the stream
+func TestArrayIterMemory(t *testing.T) {
+    var r = io.MultiReader(strings.NewReader("["), &elementsReader{n: 50000})
+    var iter = NewArrayIter(r, "")
+    var count = 0
+    for iter.Next() {
+        count++
+    }
+    if count != 50001 || iter.Err() != nil || len(iter.Errs()) != 0 || cap(iter.in.buf) > 4*streamChunk {
+        t.Errorf("%d elements, %v %v, buffer of %d bytes", count, iter.Err(), iter.Errs(), cap(iter.in.buf))
+    }
+    if loc := iter.in.location(); loc != (location{50001, 4}) {
+        t.Errorf("the array ends at %v", loc)
+    }
+}
@@ -0,0 +1,210 @@
+package json2ast
+
+// Tree is a compact ast: the nodes are kept in one slice in document order, a container
+// is followed by its subtree, and the keys of members are interned. it takes a fraction of
+// the memory and allocations of JsonAst, which is available through Node.Ast
+type Tree struct {
+    nodes []treeNode
+    keys  []string
+}
+
+type treeNode struct {
+    val     string // text of a literal
+    line    int32
+    col     int32
+    endLine int32
+    endCol  int32
+    key     int32 // index in Tree.keys of the key of a member, -1 otherwise
+    size    int32 // nodes in the subtree, the next sibling is at index+size
+    count   int32 // members or elements
+    typ     AstType
+    lit     TokenType
+}
+
+// Node is a node of a Tree, it is a small value which can be copied
+type Node struct {
+    tree *Tree
+    i    int32
+}
+
+func ParseTree(source string) (*Tree, []jsonError) {
+    return ParseTreeWithOptions(source, Options{})
+}
+
+// ParseTreeWithOptions parses the json text into a Tree as opts says
+func ParseTreeWithOptions(source string, opts Options) (*Tree, []jsonError) {
+    var b = &treeBuilder{tree: &Tree{}, key: -1, index: map[string]int32{}}
+    if errs := parseWith(source, opts, b); len(errs) != 0 {
+        return nil, errs
+    }
+    return b.tree, nil
+}
+
+// treeBuilder builds a Tree
+type treeBuilder struct {
+    tree  *Tree
+    open  []int32 // the objects and arrays being built, innermost last
+    key   int32   // of the next node
+    index map[string]int32
+}
+
+func (b *treeBuilder) act(a action, token jsonToken) {
+    var t = b.tree
+    switch a {
+    case objectAction:
+        b.open = append(b.open, b.add(Object, token))
+    case arrayAction:
+        b.open = append(b.open, b.add(Array, token))
+    case endAction:
+        var i = b.open[len(b.open)-1]
+        b.open = b.open[:len(b.open)-1]
+        var n = &t.nodes[i]
+        n.size = int32(len(t.nodes)) - i
+        n.endLine, n.endCol = int32(token.End.lineNum), int32(token.End.position)
+    case literalAction:
+        b.add(Literal, token)
+    case keyAction:
+        var k, ok = b.index[token.Val]
+        if !ok {
+            k = int32(len(t.keys))
+            t.keys = append(t.keys, token.Val)
+            b.index[token.Val] = k
+        }
+        b.key = k
+    case memberAction, elementAction:
+        t.nodes[b.open[len(b.open)-1]].count++
+    }
+}
+
+// add adds the node starting with token, a literal or the opening of a container
+func (b *treeBuilder) add(typ AstType, token jsonToken) int32 {
+    var n = treeNode{
+        line: int32(token.Loc.lineNum),
+        col: int32(token.Loc.position),
+        endLine: int32(token.End.lineNum),
+        endCol: int32(token.End.position),
+        key: b.key,
+        size: 1,
+        typ: typ,
+    }
+    if typ == Literal {
+        n.val, n.lit = token.Val, token.Typ
+    }
+    b.key = -1
+    if len(b.tree.nodes) == cap(b.tree.nodes) { // grow by doubling, append grows large slices by a quarter
+        var nodes = make([]treeNode, len(b.tree.nodes), 2*cap(b.tree.nodes)+64)
+        copy(nodes, b.tree.nodes)
+        b.tree.nodes = nodes
+    }
+    b.tree.nodes = append(b.tree.nodes, n)
+    return int32(len(b.tree.nodes) - 1)
+}
+
+func (t *Tree) Root() Node {
+    return Node{t, 0}
+}
+
+// Len is the number of nodes of the tree
+func (t *Tree) Len() int {
+    return len(t.nodes)
+}
+
+func (n Node) node() *treeNode {
+    return &n.tree.nodes[n.i]
+}
+
+func (n Node) Type() AstType {
+    return n.node().typ
+}
+
+// LiteralType is the token type of a literal: String, Number, Boolean or Null
+func (n Node) LiteralType() TokenType {
+    return n.node().lit
+}
+
+// Text is the text of a literal as written in the source, strings with their quotes
+func (n Node) Text() string {
+    return n.node().val
+}
+
+// Key is the key of the member whose value is n, as written in the source with its quotes,
+// or "" when n is not the value of a member
+func (n Node) Key() string {
+    if k := n.node().key; k >= 0 {
+        return n.tree.keys[k]
+    }
+    return ""
+}
+
+// Len is the number of members of an object (duplicates included) or elements of an array
+func (n Node) Len() int {
+    return int(n.node().count)
+}
+
+func (n Node) Loc() location {
+    var tn = n.node()
+    return location{int(tn.line), int(tn.col)}
+}
+
+// End is the location just past the last character of the node
+func (n Node) End() location {
+    var tn = n.node()
+    return location{int(tn.endLine), int(tn.endCol)}
+}
+
+// Children are the members of an object or the elements of an array, in document order
+func (n Node) Children() []Node {
+    var children = make([]Node, 0, n.Len())
+    for i, c := n.i+1, 0; c < n.Len(); c++ {
+        children = append(children, Node{n.tree, i})
+        i += n.tree.nodes[i].size
+    }
+    return children
+}
+
+// Index is the i-th element of an array
+func (n Node) Index(i int) (Node, bool) {
+    if n.Type() != Array || i < 0 || i >= n.Len() {
+        return Node{}, false
+    }
+    var j = n.i + 1
+    for ; i > 0; i-- {
+        j += n.tree.nodes[j].size
+    }
+    return Node{n.tree, j}, true
+}
+
+// Member is the value of the member of an object with the key as written in the source,
+// with its quotes like the keys of JsonAst.ObjectAst. of duplicate keys the last one is taken
+func (n Node) Member(key string) (Node, bool) {
+    if n.Type() != Object {
+        return Node{}, false
+    }
+    var found = Node{}
+    for _, c := range n.Children() {
+        if c.Key() == key { found = c }
+    }
+    return found, found.tree != nil
+}
+
+// Ast converts the subtree of n into a JsonAst
+func (n Node) Ast() JsonAst {
+    var tn = n.node()
+    var ast = JsonAst{Typ: tn.typ, Loc: n.Loc(), End: n.End()}
+    switch tn.typ {
+    case Object:
+        var obj = objectAst{members: map[string]JsonAst{}}
+        for _, c := range n.Children() {
+            obj.add(c.Key(), c.Ast())
+        }
+        ast.ObjectAst, ast.ObjectKeys = obj.members, obj.keys
+    case Array:
+        ast.ArrayAst = make([]JsonAst, 0, n.Len())
+        for _, c := range n.Children() {
+            ast.ArrayAst = append(ast.ArrayAst, c.Ast())
+        }
+    default:
+        ast.LiteralAst = literalAst{Typ: tn.lit, Val: tn.val, Loc: ast.Loc, End: ast.End}
+    }
+    return ast
+}
@@ -0,0 +1,114 @@
+package json2ast
+
+import (
+    "encoding/json"
+    "os"
+    "path/filepath"
+    "reflect"
+    "testing"
+)
+
+// astCorpus is the valid tests of the parser and the json files of testdata
+func astCorpus() []string {
+    var sources = append([]string{}, parserValidTests...)
+    files, _ := filepath.Glob(filepath.Join("testdata", "*", "*.json"))
+    suite, _ := filepath.Glob(filepath.Join("testdata", "JSONTestSuite", "test_parsing", "*.json"))
+    for _, file := range append(files, suite...) {
+        b, _ := os.ReadFile(file)
+        sources = append(sources, string(b))
+    }
+    return sources
+}
+
+// a Tree converts to the JsonAst which Parser gives, and fails with the same errors
+func TestTreeMatchesAst(t *testing.T) {
+    for _, source := range astCorpus() {
+        ast, jerrs := Parser(source)
+        tree, errs := ParseTree(source)
+        if !reflect.DeepEqual(errs, jerrs) {
+            t.Errorf("%q gives errors %v, Parser %v", source, errs, jerrs)
+            continue
+        }
+        if len(jerrs) != 0 {
+            if tree != nil { t.Errorf("%q gives a tree and errors %v", source, errs) }
+            continue
+        }
+        if got := tree.Root().Ast(); !reflect.DeepEqual(got, ast) {
+            t.Errorf("%q gives %+v, Parser %+v", source, got, ast)
+        }
+    }
+}
+
+func TestTreeNode(t *testing.T) {
+    tree, jerrs := ParseTree(`{"a": [1, {"b": null}, "x"], "c": true, "a": false}`)
+    if len(jerrs) != 0 {
+        t.Fatalf("errors %v", jerrs)
+    }
+    if tree.Len() != 8 {
+        t.Errorf("tree has %d nodes, want 8", tree.Len())
+    }
+
+    var root = tree.Root()
+    if root.Type() != Object || root.Len() != 3 || len(root.Children()) != 3 {
+        t.Fatalf("root is %v with %d members", root.Type(), root.Len())
+    }
+    if a, ok := root.Member(`"a"`); !ok || a.Type() != Literal || a.Text() != "false" {
+        t.Errorf(`member "a" is %v %q, want the last one`, a.Type(), a.Text())
+    }
+
+    var array = root.Children()[0]
+    if array.Key() != `"a"` || array.Type() != Array || array.Loc() != (location{1, 7}) || array.End() != (location{1, 28}) {
+        t.Errorf("first member is %q %v at %v-%v", array.Key(), array.Type(), array.Loc(), array.End())
+    }
+    var wants = []string{"1", "", `"x"`}
+    for i, want := range wants {
+        if e, ok := array.Index(i); !ok || e.Text() != want || e.Key() != "" {
+            t.Errorf("element %d is %q, want %q", i, e.Text(), want)
+        }
+    }
+    if _, ok := array.Index(3); ok {
+        t.Errorf("element 3 is found")
+    }
+    obj, _ := array.Index(1)
+    if b, ok := obj.Member(`"b"`); !ok || b.LiteralType() != Null || b.Key() != `"b"` {
+        t.Errorf(`member "b" is %v`, b.LiteralType())
+    }
+    if _, ok := obj.Member(`"a"`); ok {
+        t.Errorf(`member "a" is found in %v`, obj.Ast())
+    }
+}
+
+func BenchmarkParser(b *testing.B) {
+    var source = benchmarkDocument()
+
+    b.Run("JsonAst", func(b *testing.B) {
+        b.SetBytes(int64(len(source)))
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            Parser(source)
+        }
+    })
+    b.Run("Tree", func(b *testing.B) {
+        b.SetBytes(int64(len(source)))
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            ParseTree(source)
+        }
+    })
+    b.Run("Events", func(b *testing.B) {
+        b.SetBytes(int64(len(source)))
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            ParseEvents(source, Options{}, func(e Event) Signal { return Continue })
+        }
+    })
+    b.Run("encoding/json", func(b *testing.B) {
+        var data = []byte(source)
+        b.SetBytes(int64(len(source)))
+        b.ReportAllocs()
+        for i := 0; i < b.N; i++ {
+            var v interface{}
+            json.Unmarshal(data, &v)
+        }
+    })
+}